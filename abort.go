@@ -0,0 +1,36 @@
+package router
+
+import (
+	"context"
+	"net/http"
+)
+
+// abortContextKey stores the state of a request that may be aborted.
+var abortContextKey = &contextKey{"abort"}
+
+// abortState records whether the request was aborted.
+type abortState struct {
+	aborted bool
+}
+
+// Abort marks the request as failed, so that Router.OnFinish is not called
+// for it. Handlers and middleware call it when they give up on a request,
+// for example after responding with an error, and the response they have
+// written is sent as usual. Abort does not stop the handler or middleware
+// that calls it.
+func Abort(r *http.Request) {
+	if st, ok := r.Context().Value(abortContextKey).(*abortState); ok {
+		st.aborted = true
+	}
+}
+
+// Aborted reports whether Abort was called for the request.
+func Aborted(r *http.Request) bool {
+	st, ok := r.Context().Value(abortContextKey).(*abortState)
+	return ok && st.aborted
+}
+
+// withAbort returns request with the state used by Abort stored in context.
+func withAbort(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), abortContextKey, &abortState{}))
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAbort(t *testing.T) {
+	var finished []string
+
+	rt := New()
+	rt.OnFinish = func(w http.ResponseWriter, r *http.Request) {
+		finished = append(finished, r.URL.Path)
+	}
+
+	// Middleware may abort the request.
+	rt.Use(func(h HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, ps Params) {
+			if r.URL.Query().Get("deny") != "" {
+				Abort(r)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			h(w, r, ps)
+		}
+	})
+
+	rt.Get("/ok", reply("ok"))
+	rt.Get("/fail", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.WriteHeader(http.StatusBadRequest)
+		Abort(r)
+	})
+	rt.Get("/panic", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		panic("handler")
+	})
+
+	for _, path := range []string{"/ok", "/fail", "/ok?deny=1", "/panic"} {
+		serve(rt, "GET", path)
+	}
+
+	if got := fmt.Sprint(finished); got != "[/ok]" {
+		t.Errorf("finished %s, want [/ok]", got)
+	}
+
+	// Abort has no effect on requests that are not served by a router.
+	req := httptest.NewRequest("GET", "/", nil)
+	Abort(req)
+	if Aborted(req) {
+		t.Error("request aborted")
+	}
+}
@@ -0,0 +1,118 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// ErrBindTarget is returned by BindParams if the destination is not
+// a non-nil pointer to a struct.
+var ErrBindTarget error = errors.New("router: bind destination must be a non-nil pointer to a struct")
+
+// BindParams sets fields of the struct pointed to by dst from parameters.
+// Fields are bound by the "param" tag with the parameter name:
+//
+//	var q struct {
+//		ID   int      `param:"id"`
+//		Tags []string `param:"tags"`
+//	}
+//	err := BindParams(ps, &q)
+//
+// Supported field types are strings, booleans, integers, floating point
+// numbers and slices of them. A scalar field receives the first value of the
+// parameter, and a slice field receives all values. Fields of missing
+// parameters are left unchanged. If a value cannot be parsed, an error
+// naming the parameter is returned.
+func BindParams(ps Params, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrBindTarget
+	}
+
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		// Get name of the parameter.
+		name, ok := t.Field(i).Tag.Lookup("param")
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+
+		values := ps.GetAll(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		f := v.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+
+		// Fill slice with all values.
+		if f.Kind() == reflect.Slice {
+			s := reflect.MakeSlice(f.Type(), len(values), len(values))
+			for j, value := range values {
+				if err := setValue(s.Index(j), value); err != nil {
+					return bindError(name, value, err)
+				}
+			}
+
+			f.Set(s)
+			continue
+		}
+
+		// Set scalar to the first value.
+		if err := setValue(f, values[0]); err != nil {
+			return bindError(name, values[0], err)
+		}
+	}
+
+	return nil
+}
+
+// bindError returns error of parameter value parsing.
+func bindError(name string, value string, err error) error {
+	return fmt.Errorf("router: cannot bind parameter %q value %q: %w", name, value, err)
+}
+
+// setValue parses the string and sets the value.
+func setValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type searchQuery struct {
+	ID   int      `param:"id"`
+	Tags []string `param:"tags"`
+	IDs  []int    `param:"ids"`
+}
+
+func TestBindParams(t *testing.T) {
+	var q searchQuery
+	var err error
+
+	rt := New()
+	rt.Get("/search/:id", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		q = searchQuery{}
+		err = BindParams(ps, &q)
+	})
+
+	serve(rt, "GET", "/search/3?tags=a&tags=b&ids=1&ids=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := searchQuery{ID: 3, Tags: []string{"a", "b"}, IDs: []int{1, 2}}
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("got %+v, want %+v", q, want)
+	}
+
+	// Error names the parameter and the value of the element.
+	serve(rt, "GET", "/search/3?ids=1&ids=x")
+	if !errors.Is(err, strconv.ErrSyntax) || err.Error() != `router: cannot bind parameter "ids" value "x": strconv.ParseInt: parsing "x": invalid syntax` {
+		t.Errorf("got %v", err)
+	}
+
+	if err := BindParams(NewParams(), q); err != ErrBindTarget {
+		t.Errorf("got %v for non-pointer, want %v", err, ErrBindTarget)
+	}
+}
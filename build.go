@@ -0,0 +1,56 @@
+package router
+
+import (
+	"errors"
+)
+
+// Build checks the route table before the router starts serving requests
+// and returns an error describing all problems found, or nil if there are
+// none. It reports:
+//
+//   - errors of the routes and mounted routers that failed to register, as
+//     RegistrationErrors, even if they were already returned, for example
+//     by Handle, whose result is easy to ignore;
+//   - shadowed patterns, as returned by CheckShadowed.
+//
+// Mounted and host routers are checked too. Patterns are parsed and
+// their regular expressions compiled when they are registered, so there is
+// nothing to prepare for the first request. Routes may still be registered
+// after Build, and Build may be called again to check them.
+func (r *Router) Build() error {
+	r.mu.RLock()
+	errs := append(RegistrationErrors(nil), r.errs...)
+	r.mu.RUnlock()
+
+	hosts := r.loadConfig().hostRouters()
+
+	var all []error
+	if len(errs) > 0 {
+		all = append(all, errs)
+	}
+
+	if err := r.CheckShadowed(); err != nil {
+		all = append(all, err)
+	}
+
+	// Check mounted and host routers.
+	for _, pd := range r.table.Load().sorted() {
+		if pd.mount != nil {
+			all = append(all, pd.mount.Build())
+		}
+	}
+
+	for _, hr := range hosts {
+		all = append(all, hr.Build())
+	}
+
+	return errors.Join(all...)
+}
+
+// addError records the error of a route that failed to register.
+func (r *Router) addError(method string, pattern string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errs = append(r.errs, &RouteError{Method: method, Pattern: pattern, Err: err})
+}
@@ -0,0 +1,31 @@
+package router
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	rt, sub := New(), New()
+	rt.Get("/a", reply("a"))
+	rt.Mount("/sub", sub)
+
+	if err := rt.Build(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	// Errors returned by Handle are reported, even if they were ignored.
+	rt.Get("/items/:id([)", reply("item"))
+	sub.Get("/a", reply("a"))
+	sub.Get("/a", reply("again"))
+
+	err := rt.Build()
+	if !errors.Is(err, ErrParameterPattern) || !errors.Is(err, ErrDuplicateHandler) {
+		t.Fatalf("got %v, want both %v and %v", err, ErrParameterPattern, ErrDuplicateHandler)
+	}
+
+	var re *RouteError
+	if !errors.As(err, &re) || re.Method != "GET" || re.Pattern != "/items/:id([)" {
+		t.Errorf("got %v, want error of GET /items/:id([)", err)
+	}
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"strings"
+)
+
+// originalSegments returns segments of the path like splitPath of the
+// normalized path does, but in original case. Returns nil if lowercasing
+// changes the length of a segment, so that parameter values cannot be
+// located in the original segments.
+func originalSegments(p string) []string {
+	segs := splitPath(cleanSlashes(strings.TrimRight(p, "/")))
+	lower := splitPath(normalizePath(p))
+	if len(segs) != len(lower) {
+		return nil
+	}
+
+	for i := range segs {
+		if len(segs[i]) != len(lower[i]) {
+			return nil
+		}
+	}
+
+	return segs
+}
+
+// originalValues returns parameter values and the rest of the path for
+// mounted router taken from the original segments of the matched path.
+func (pd *pathData) originalValues(segs []string) ([]string, string) {
+	values := make([]string, 0, len(pd.params))
+	for i, seg := range pd.segments {
+		switch {
+		case seg.catchAll:
+			values = append(values, strings.Join(segs[i:], "/"))
+		case seg.param:
+			values = append(values, segs[i][:len(segs[i])-len(seg.suffix)])
+		}
+	}
+
+	rest := ""
+	if pd.mount != nil {
+		rest = "/" + strings.Join(segs[len(pd.segments):], "/")
+	}
+
+	return values, rest
+}
+
+// lowercasePath returns the matched path in lower case. If PreserveParamCase
+// is set, only static segments of the pattern are lowercased.
+func (router *Router) lowercasePath(pd *pathData, p string) string {
+	segs := originalSegments(p)
+	if !router.PreserveParamCase || segs == nil {
+		return strings.ToLower(p)
+	}
+
+	for i := range segs {
+		// Parameter values and the rest of the path for mounted router are
+		// kept as is.
+		if i >= len(pd.segments) || pd.segments[i].catchAll {
+			break
+		}
+
+		if !pd.segments[i].param {
+			segs[i] = strings.ToLower(segs[i])
+		}
+	}
+
+	s := "/" + strings.Join(segs, "/")
+	if hasTrailingSlash(p) && s != "/" {
+		s += "/"
+	}
+
+	return s
+}
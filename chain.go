@@ -0,0 +1,91 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+)
+
+// chainContextKey stores the state of the current handler in a chain.
+var chainContextKey = &contextKey{"chain"}
+
+// chainState records whether the handler asked to run the next one.
+type chainState struct {
+	next bool
+}
+
+// HandleChain sets an ordered list of handlers for specific method and
+// pattern. Handlers run one after another while each of them calls Next
+// and does not write the response. The chain stops when a handler returns
+// without calling Next, or when it has written the status code or a part
+// of the body, even if it called Next. Middleware wraps the whole chain.
+//
+//	err := HandleChain("GET", "/api/orders", auditHandler, ordersHandler)
+func (r *Router) HandleChain(method string, pattern string, handlers ...HandlerFunc) error {
+	return r.HandleWith(method, pattern, runChain(handlers), func(rt *route) {
+		rt.chain = handlers
+	})
+}
+
+// Next makes the chain registered with HandleChain proceed to the next
+// handler after the current one returns. It does nothing for handlers that
+// are not part of a chain.
+func Next(r *http.Request) {
+	if st, ok := r.Context().Value(chainContextKey).(*chainState); ok {
+		st.next = true
+	}
+}
+
+// runChain returns handler that runs the chain of handlers.
+func runChain(handlers []HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, ps Params) {
+		cw := &chainResponseWriter{ResponseWriter: w}
+		for _, h := range handlers {
+			st := &chainState{}
+			h(cw, r.WithContext(context.WithValue(r.Context(), chainContextKey, st)), ps)
+
+			// Stop if handler did not ask to proceed or already responded.
+			if !st.next || cw.written {
+				return
+			}
+		}
+	}
+}
+
+// chainResponseWriter records whether a response was written.
+type chainResponseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *chainResponseWriter) WriteHeader(status int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *chainResponseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client if the underlying writer
+// supports it. Flushing sends the header, so the chain stops.
+func (w *chainResponseWriter) Flush() {
+	w.written = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection if the underlying
+// writer supports it. The handler responds itself, so the chain stops.
+func (w *chainResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.written = true
+	return hijack(w.ResponseWriter)
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *chainResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
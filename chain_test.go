@@ -0,0 +1,52 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestHandleChain(t *testing.T) {
+	var calls []string
+	record := func(name string, next bool) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, ps Params) {
+			calls = append(calls, name)
+			if next {
+				Next(r)
+			}
+		}
+	}
+
+	write := func(w http.ResponseWriter, r *http.Request, ps Params) {
+		calls = append(calls, "write")
+		w.Write([]byte("written"))
+		Next(r)
+	}
+
+	rt := New()
+	rt.HandleChain("GET", "/next", record("audit", true), record("real", false), record("never", false))
+	rt.HandleChain("GET", "/stop", record("real", false), record("never", false))
+	rt.HandleChain("GET", "/write", write, record("never", false))
+
+	tests := []struct {
+		path  string
+		calls string
+	}{
+		// Next continues the chain.
+		{"/next", "[audit real]"},
+
+		// Returning without Next stops it.
+		{"/stop", "[real]"},
+
+		// Writing the response stops it even if Next is called.
+		{"/write", "[write]"},
+	}
+
+	for _, tt := range tests {
+		calls = nil
+		serve(rt, "GET", tt.path)
+		if got := fmt.Sprint(calls); got != tt.calls {
+			t.Errorf("%s: got calls %s, want %s", tt.path, got, tt.calls)
+		}
+	}
+}
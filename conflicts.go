@@ -0,0 +1,192 @@
+package router
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// A Conflict describes two patterns that match some of the same paths.
+// Such paths are handled by the routes of the preferred Pattern, as
+// described for Router.Handle, and never reach the routes of Other.
+type Conflict struct {
+	Pattern string
+	Other   string
+}
+
+// A ShadowError describes a pattern that never handles requests, because
+// every path it matches is handled by the routes of a preferred pattern.
+type ShadowError struct {
+	Pattern  string
+	Shadowed string
+}
+
+func (e *ShadowError) Error() string {
+	return "router: pattern " + e.Shadowed + " is shadowed by " + e.Pattern
+}
+
+// CheckShadowed returns an error if some registered pattern is shadowed:
+// every path it matches also matches a preferred pattern, for example
+// "/files/:name" registered with lower priority than "/files/*path". The
+// returned error joins a *ShadowError for every shadowed pattern, in
+// matching order of the shadowing patterns. Returns nil if all patterns
+// are reachable.
+//
+// Like CheckConflicts, it is a static analysis that ignores methods,
+// because a path is handled by the routes of the first matching pattern
+// only. Patterns with routes registered by HandleIf do not shadow other
+// patterns, and mounted routers may be shadowed only. Host routers are
+// not checked.
+func (r *Router) CheckShadowed() error {
+	pds := r.table.Load().sorted()
+	sort.SliceStable(pds, func(i, j int) bool {
+		return pds[i].morePreferred(pds[j])
+	})
+
+	var errs []error
+	for i, pd := range pds {
+		// Predicates may make the router skip the pattern.
+		if pd.conditional || pd.mount != nil {
+			continue
+		}
+
+		for _, other := range pds[i+1:] {
+			if pd.covers(other) {
+				errs = append(errs, &ShadowError{Pattern: pd.pattern(), Shadowed: other.pattern()})
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CheckConflicts returns pairs of registered patterns that match some of
+// the same paths, regardless of methods: a path is handled by the routes of
+// the first matching pattern only, even if they are registered for other
+// methods. Conflicts are sorted in matching order of the preferred patterns.
+//
+// Two patterns conflict if they have the same number of segments, or one of
+// them ends with a catch-all parameter that captures the rest of the other,
+// and all their segments can match the same value: equal static segments,
+// a static segment that a named parameter accepts, or two named parameters
+// with compatible literal suffixes. Regular expressions of parameters are
+// not compared, so parameters constrained by different expressions are
+// assumed not to conflict. Mounted routers and host routers are not checked.
+//
+// It is a static analysis of the route table and has no effect on request
+// handling.
+func (r *Router) CheckConflicts() []Conflict {
+	t := r.table.Load()
+
+	// Sort path data in matching order.
+	pds := make([]*pathData, 0, len(t.routes))
+	for _, pd := range t.routes {
+		if pd.mount == nil {
+			pds = append(pds, pd)
+		}
+	}
+
+	sort.Slice(pds, func(i, j int) bool {
+		return pds[i].morePreferred(pds[j])
+	})
+
+	var conflicts []Conflict
+	for i, pd := range pds {
+		for _, other := range pds[i+1:] {
+			// Different static paths never match the same path.
+			if pd.static() && other.static() {
+				continue
+			}
+
+			if pd.overlaps(other) {
+				conflicts = append(conflicts, Conflict{Pattern: pd.pattern(), Other: other.pattern()})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// overlaps reports whether some path matches both patterns.
+func (pd *pathData) overlaps(other *pathData) bool {
+	a, b := pd.segments, other.segments
+	for i := 0; ; i++ {
+		// Catch-all parameter matches any rest of the path.
+		if i < len(a) && a[i].catchAll || i < len(b) && b[i].catchAll {
+			return true
+		}
+
+		if i == len(a) || i == len(b) {
+			return len(a) == len(b)
+		}
+
+		if !a[i].overlaps(b[i]) {
+			return false
+		}
+	}
+}
+
+// overlaps reports whether some path segment matches both segments.
+func (seg segment) overlaps(other segment) bool {
+	switch {
+	case !seg.param && !other.param:
+		return seg.value == other.value
+	case !seg.param:
+		_, ok := other.accept(seg.value)
+		return ok
+	case !other.param:
+		_, ok := seg.accept(other.value)
+		return ok
+	}
+
+	// Values of both parameters have to end with the longer suffix.
+	if !strings.HasSuffix(seg.suffix, other.suffix) && !strings.HasSuffix(other.suffix, seg.suffix) {
+		return false
+	}
+
+	// Expressions are not compared.
+	if seg.re != nil && other.re != nil {
+		return seg.expr == other.expr
+	}
+
+	return true
+}
+
+// covers reports whether every path that matches other matches the pattern.
+func (pd *pathData) covers(other *pathData) bool {
+	a, b := pd.segments, other.segments
+	for i := 0; ; i++ {
+		// Catch-all parameter matches any rest of the path, and mounted
+		// router matches any longer path.
+		if i < len(a) && a[i].catchAll || i == len(a) && pd.mount != nil && i <= len(b) {
+			return true
+		}
+
+		// Other pattern matches longer paths if it has a catch-all parameter
+		// or a mounted router.
+		if i == len(a) || i == len(b) {
+			return len(a) == len(b) && other.mount == nil
+		}
+
+		if b[i].catchAll || !a[i].covers(b[i]) {
+			return false
+		}
+	}
+}
+
+// covers reports whether every value that matches other segment matches
+// the segment.
+func (seg segment) covers(other segment) bool {
+	switch {
+	case !seg.param:
+		return !other.param && seg.value == other.value
+	case !other.param:
+		_, ok := seg.accept(other.value)
+		return ok
+	case seg.re != nil:
+		// Expressions are not compared.
+		return other.re != nil && seg.expr == other.expr && seg.suffix == other.suffix
+	}
+
+	return strings.HasSuffix(other.suffix, seg.suffix)
+}
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCheckConflicts(t *testing.T) {
+	rt := New()
+	rt.Get("/users/42", reply(""))
+	rt.Get("/users/me", reply(""))
+	rt.Get(`/users/:id(\d+)`, reply(""))
+	rt.Get("/files/:name.json", reply(""))
+	rt.Get("/files/*path", reply(""))
+	rt.Get("/a/b", reply(""))
+
+	want := []Conflict{
+		{`/users/42`, `/users/:id(\d+)`},
+		{"/files/:name.json", "/files/*path"},
+	}
+	if got := rt.CheckConflicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCheckShadowed(t *testing.T) {
+	rt := New()
+	rt.Get("/files/*path", reply(""))
+	rt.Get("/files/:name", reply(""))
+	rt.Get(`/a/:x(\d+)`, reply(""))
+	rt.Get("/a/:y", reply(""))
+
+	if err := rt.CheckShadowed(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	rt.HandleWith("GET", "/files/:name/x", reply(""), WithPriority(-1))
+	rt.HandleWith("GET", "/files/readme", reply(""), WithPriority(-1))
+
+	err := rt.CheckShadowed()
+
+	var se *ShadowError
+	if !errors.As(err, &se) || se.Pattern != "/files/:name" {
+		t.Fatalf("got %v, want pattern shadowed by /files/:name", err)
+	}
+
+	var errs []error
+	if u, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = u.Unwrap()
+	}
+
+	for _, e := range errs {
+		if _, ok := e.(*ShadowError); !ok {
+			t.Errorf("got %T, want *ShadowError", e)
+		}
+	}
+	if len(errs) != 3 {
+		t.Errorf("got %d errors, want 3: %v", len(errs), err)
+	}
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"net/url"
+	"strings"
+)
+
+// escapedRest returns the value of catch-all parameter that starts at the
+// n-th segment of the path with encoded slashes kept. Returns false if the
+// escaped path does not have the same first n segments as the path. If
+// lower is true, the value is in lower case, except for the escapes.
+func escapedRest(u *url.URL, n int, lower bool) (string, bool) {
+	segs := splitPath(cleanSlashes(strings.TrimRight(u.EscapedPath(), "/")))
+	plain := splitPath(cleanSlashes(strings.TrimRight(u.Path, "/")))
+	if len(segs) < n || len(plain) < n {
+		return "", false
+	}
+
+	// Segments before the parameter must not contain encoded slashes.
+	for i := 0; i < n; i++ {
+		if v, err := url.PathUnescape(segs[i]); err != nil || v != plain[i] {
+			return "", false
+		}
+	}
+
+	rest := strings.Join(segs[n:], "/")
+	rest = strings.ReplaceAll(rest, "%2f", "%2F")
+
+	// Decode everything except slashes.
+	parts := strings.Split(rest, "%2F")
+	for i, p := range parts {
+		v, err := url.PathUnescape(p)
+		if err != nil {
+			return "", false
+		}
+
+		if lower {
+			v = strings.ToLower(v)
+		}
+
+		parts[i] = v
+	}
+
+	return strings.Join(parts, "%2F"), true
+}
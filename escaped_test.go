@@ -0,0 +1,30 @@
+package router
+
+import "testing"
+
+func TestEncodedSlashes(t *testing.T) {
+	rt := New()
+	rt.Get("/files/*path", echo)
+
+	if w := serve(rt, "GET", "/files/a%2Fb/c"); w.Body.String() != "/files/a/b/c map[path:[a/b/c]]" {
+		t.Errorf("got %q without EncodedSlashes", w.Body.String())
+	}
+
+	rt.EncodedSlashes = true
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/files/a%2Fb/c%20d", "/files/a/b/c d map[path:[a%2Fb/c d]]"},
+		{"/files/A%2fB", "/files/A/B map[path:[a%2Fb]]"},
+		{"/files/a/b", "/files/a/b map[path:[a/b]]"},
+		{"/files", "/files map[path:[]]"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s: got %q, want %q", tt.path, w.Body.String(), tt.body)
+		}
+	}
+}
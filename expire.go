@@ -0,0 +1,143 @@
+package router
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ExpireUnused starts removing routes that have not handled a request for
+// the ttl, for example routes registered by plugins that went away, and
+// returns a function that stops it. Routes are checked in the background
+// every half of the ttl, so a route is removed between ttl and 1.5 ttl after
+// its last request. The time of a route is counted from its registration
+// or the call of ExpireUnused, whichever is later.
+//
+// All routes of the router are subject to expiration, including routes
+// registered before the call, but routes of mounted and host routers are
+// not. Routes that must stay can be registered in another router that
+// the expiring one is mounted to. Removing a route is like Remove, but only
+// the expired route is removed from the routes for the method and pattern.
+// ExpireUnused panics if the ttl is not positive.
+//
+// Calling ExpireUnused again replaces the running expiration, for example
+// to change the ttl, and the stop function of the replaced one does
+// nothing. Request times are not tracked after stop.
+func (r *Router) ExpireUnused(ttl time.Duration) (stop func()) {
+	if ttl <= 0 {
+		panic("router: ExpireUnused requires positive ttl")
+	}
+
+	// Tick at least every nanosecond for the shortest ttl.
+	interval := ttl / 2
+	if interval == 0 {
+		interval = 1
+	}
+
+	// Count the time of existing routes from now.
+	now := time.Now().UnixNano()
+	for _, pd := range r.table.Load().routes {
+		for _, routes := range pd.methods {
+			for _, rt := range routes {
+				atomic.StoreInt64(&rt.lastUsed, now)
+			}
+		}
+	}
+
+	// Replace the running expiration.
+	r.mu.Lock()
+	if r.sweeper != nil {
+		close(r.sweeper)
+	}
+
+	done := make(chan struct{})
+	r.sweeper = done
+	r.trackUse.Store(true)
+	r.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case t := <-ticker.C:
+				r.removeUnused(t.Add(-ttl).UnixNano())
+			}
+		}
+	}()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		// The replaced expiration was stopped by ExpireUnused.
+		if r.sweeper != done {
+			return
+		}
+
+		close(done)
+		r.sweeper = nil
+		r.trackUse.Store(false)
+	}
+}
+
+// removeUnused removes routes that were last used before the time.
+func (r *Router) removeUnused(before int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.table.Load().clone()
+	changed := false
+	for _, v := range t.routes {
+		if v.mount != nil || !v.hasUnused(before) {
+			continue
+		}
+
+		pd := v.clone()
+		for m, routes := range pd.methods {
+			used := routes[:0]
+			for _, rt := range routes {
+				if atomic.LoadInt64(&rt.lastUsed) >= before {
+					used = append(used, rt)
+				}
+			}
+
+			if len(used) == 0 {
+				delete(pd.methods, m)
+			} else {
+				pd.methods[m] = used
+			}
+		}
+
+		// Remove path data when the last method is removed.
+		if len(pd.methods) == 0 {
+			t.remove(v)
+		} else {
+			pd.resetFlags()
+			t.replace(v, pd)
+		}
+
+		changed = true
+	}
+
+	if changed {
+		t.sort()
+		r.table.Store(t)
+	}
+}
+
+// hasUnused reports whether the path data has routes last used before the
+// time.
+func (pd *pathData) hasUnused(before int64) bool {
+	for _, routes := range pd.methods {
+		for _, rt := range routes {
+			if atomic.LoadInt64(&rt.lastUsed) < before {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestExpireUnused(t *testing.T) {
+	const ttl = 60 * time.Millisecond
+
+	rt := New()
+	rt.Get("/used", reply("used"))
+	rt.Post("/used", reply("used"))
+	rt.Get("/unused", reply("unused"))
+
+	stop := rt.ExpireUnused(ttl)
+	defer stop()
+
+	// Keep requesting one route until well past the ttl of the others.
+	for start := time.Now(); time.Since(start) < 3*ttl; time.Sleep(ttl / 6) {
+		serve(rt, "GET", "/used")
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/used", http.StatusOK},
+		{"POST", "/used", http.StatusMethodNotAllowed},
+		{"GET", "/unused", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, tt.method, tt.path); w.Code != tt.status {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, w.Code, tt.status)
+		}
+	}
+
+	// Routes stay after stop.
+	stop()
+	stop()
+	time.Sleep(2 * ttl)
+
+	if w := serve(rt, "GET", "/used"); w.Code != http.StatusOK {
+		t.Errorf("got %d after stop, want 200", w.Code)
+	}
+}
+
+func TestExpireUnusedReplace(t *testing.T) {
+	const ttl = 20 * time.Millisecond
+
+	rt := New()
+	rt.Get("/a", reply("a"))
+
+	// The replaced expiration does not remove routes.
+	first := rt.ExpireUnused(ttl)
+	second := rt.ExpireUnused(time.Hour)
+	time.Sleep(3 * ttl)
+
+	if w := serve(rt, "GET", "/a"); w.Code != http.StatusOK {
+		t.Errorf("got %d, want 200 after replacing the expiration", w.Code)
+	}
+
+	// Stopping the replaced expiration does not stop the running one.
+	first()
+	if !rt.trackUse.Load() {
+		t.Error("use not tracked after stopping the replaced expiration")
+	}
+
+	second()
+	if rt.trackUse.Load() {
+		t.Error("use tracked after stop")
+	}
+}
+
+func TestExpireUnusedTTL(t *testing.T) {
+	// The shortest ttl works.
+	New().ExpireUnused(time.Nanosecond)()
+
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: no panic", ttl)
+				}
+			}()
+
+			New().ExpireUnused(ttl)
+		}()
+	}
+}
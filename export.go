@@ -0,0 +1,167 @@
+package router
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Errors of importing routes.
+var (
+	ErrRouteData      error = errors.New("router: invalid route data")
+	ErrUnknownHandler error = errors.New("router: no handler with the route name")
+)
+
+// Header of the data returned by ExportRoutes, with the format version.
+const exportHeader = "rt\x01"
+
+// ExportRoutes returns the named routes of the router in a compact binary
+// form, that ImportRoutes registers again, for example in another process
+// with the same handlers. For every route it keeps the method, the pattern
+// as it was registered, the name, the priority and the content types.
+// Routes without names, routes of mounted and host routers, and other
+// options, like middleware, predicates or metadata, are not exported.
+func (r *Router) ExportRoutes() []byte {
+	b := []byte(exportHeader)
+	for _, pd := range r.table.Load().sorted() {
+		if pd.mount != nil {
+			continue
+		}
+
+		for _, m := range pd.allowedMethods() {
+			for _, rt := range pd.methods[m] {
+				if rt.name == "" {
+					continue
+				}
+
+				b = appendString(b, m)
+				b = appendString(b, rt.template)
+				b = appendString(b, rt.name)
+				b = binary.AppendVarint(b, int64(rt.priority))
+				b = binary.AppendUvarint(b, uint64(len(rt.contentTypes)))
+				for _, ct := range rt.contentTypes {
+					b = appendString(b, ct)
+				}
+			}
+		}
+	}
+
+	return b
+}
+
+// ImportRoutes registers the routes exported by ExportRoutes with the
+// handlers found by route names. Like Register, it does not stop on the
+// first error: routes with unknown names fail with ErrUnknownHandler, and
+// errors of all failed routes are returned as RegistrationErrors. Returns
+// ErrRouteData without registering any routes if the data is malformed.
+func (r *Router) ImportRoutes(data []byte, handlers map[string]HandlerFunc) error {
+	routes, err := decodeRoutes(data)
+	if err != nil {
+		return err
+	}
+
+	var errs RegistrationErrors
+	for _, e := range routes {
+		h, ok := handlers[e.name]
+		if !ok {
+			errs = append(errs, &RouteError{Method: e.method, Pattern: e.pattern, Err: ErrUnknownHandler})
+			continue
+		}
+
+		opts := []RouteOption{WithName(e.name), WithPriority(e.priority)}
+		if len(e.contentTypes) > 0 {
+			opts = append(opts, WithContentType(e.contentTypes...))
+		}
+
+		if err := r.HandleWith(e.method, e.pattern, h, opts...); err != nil {
+			errs = append(errs, &RouteError{Method: e.method, Pattern: e.pattern, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// exportedRoute is a route decoded from the data of ExportRoutes.
+type exportedRoute struct {
+	method       string
+	pattern      string
+	name         string
+	priority     int
+	contentTypes []string
+}
+
+// decodeRoutes decodes the data of ExportRoutes.
+func decodeRoutes(data []byte) ([]exportedRoute, error) {
+	if !bytes.HasPrefix(data, []byte(exportHeader)) {
+		return nil, ErrRouteData
+	}
+
+	br := bytes.NewReader(data[len(exportHeader):])
+
+	var routes []exportedRoute
+	for br.Len() > 0 {
+		var e exportedRoute
+		var err error
+		if e.method, err = readString(br); err != nil {
+			return nil, err
+		}
+
+		if e.pattern, err = readString(br); err != nil {
+			return nil, err
+		}
+
+		if e.name, err = readString(br); err != nil {
+			return nil, err
+		}
+
+		priority, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, ErrRouteData
+		}
+
+		n, err := binary.ReadUvarint(br)
+		if err != nil || n > uint64(br.Len()) {
+			return nil, ErrRouteData
+		}
+
+		e.priority = int(priority)
+		for i := uint64(0); i < n; i++ {
+			ct, err := readString(br)
+			if err != nil {
+				return nil, err
+			}
+
+			e.contentTypes = append(e.contentTypes, ct)
+		}
+
+		routes = append(routes, e)
+	}
+
+	return routes, nil
+}
+
+// appendString appends the length-prefixed string.
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// readString reads the length-prefixed string.
+func readString(br *bytes.Reader) (string, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil || n > uint64(br.Len()) {
+		return "", ErrRouteData
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(br, b); err != nil {
+		return "", ErrRouteData
+	}
+
+	return string(b), nil
+}
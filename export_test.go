@@ -0,0 +1,71 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExportRoutes(t *testing.T) {
+	rt := New()
+	rt.HandleWith("GET", "/users/:id", reply("user"), WithName("user"))
+	rt.HandleWith("POST", "/upload", reply("upload"), WithName("upload"),
+		WithContentType("application/json"), WithPriority(3))
+	rt.Get("/anonymous", reply("anonymous"))
+
+	data := rt.ExportRoutes()
+
+	imported := New()
+	err := imported.ImportRoutes(data, map[string]HandlerFunc{
+		"user":   reply("imported user"),
+		"upload": reply("imported upload"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The imported routes export the same data.
+	if got := imported.ExportRoutes(); !bytes.Equal(got, data) {
+		t.Errorf("got export %q, want %q", got, data)
+	}
+
+	var routes []string
+	for _, ri := range imported.Routes() {
+		routes = append(routes, ri.Method+" "+ri.Pattern+" "+ri.Name)
+	}
+	if want := []string{"POST /upload upload", "GET /users/:id user"}; !reflect.DeepEqual(routes, want) {
+		t.Errorf("got routes %q, want %q", routes, want)
+	}
+
+	tests := []struct {
+		method      string
+		path        string
+		contentType string
+		status      int
+		body        string
+	}{
+		{"GET", "/users/1", "", http.StatusOK, "imported user"},
+		{"POST", "/upload", "application/json", http.StatusOK, "imported upload"},
+		{"POST", "/upload", "text/plain", http.StatusUnsupportedMediaType, ""},
+		{"GET", "/anonymous", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		w := serve(imported, tt.method, tt.path, "Content-Type", tt.contentType)
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s %s: got %d %q, want %d %q", tt.method, tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+
+	if err := New().ImportRoutes(data, map[string]HandlerFunc{"user": reply("")}); !errors.Is(err, ErrUnknownHandler) {
+		t.Errorf("got %v, want %v", err, ErrUnknownHandler)
+	}
+
+	for _, bad := range [][]byte{data[:len(data)-2], []byte("xx")} {
+		if err := New().ImportRoutes(bad, nil); err != ErrRouteData {
+			t.Errorf("%q: got %v, want %v", bad, err, ErrRouteData)
+		}
+	}
+}
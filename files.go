@@ -0,0 +1,168 @@
+package router
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ErrFilesPattern is returned by Router.ServeFiles and Router.ServeFS if
+// the pattern does not end with a catch-all parameter.
+var ErrFilesPattern error = errors.New("router: pattern for serving files must end with a catch-all parameter")
+
+// ServeFile adds GET and HEAD handler that responds with the contents of
+// the named file, for example:
+//
+//	err := ServeFile("/favicon.ico", "./static/favicon.ico")
+//
+// Content type is detected from the file extension or content. If the file
+// does not exist or is a directory, the handler responds with 404 Not Found.
+// Responses to HEAD requests have the headers of the file, but no body.
+// Unlike http.ServeFile, requests are never redirected, so the pattern may
+// end with "/index.html".
+func (r *Router) ServeFile(pattern string, name string) error {
+	return r.getAndHead(pattern, func(w http.ResponseWriter, req *http.Request, _ Params) {
+		serveFile(w, req, name)
+	})
+}
+
+// serveFile responds with the contents of the named file.
+func serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	f, err := os.Open(name)
+	if err != nil {
+		w.WriteHeader(fileErrorStatus(err))
+		return
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		w.WriteHeader(fileErrorStatus(err))
+		return
+	}
+
+	if st.IsDir() {
+		// Set status code to 404 Not Found.
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	http.ServeContent(w, r, st.Name(), st.ModTime(), f)
+}
+
+// fileErrorStatus returns the status code for the error of opening a file,
+// like http.ServeFile does.
+func fileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ServeFS adds GET and HEAD handler that serves files from the file
+// system, for example embed.FS. The pattern must end with a catch-all
+// parameter, that captures the name of the file:
+//
+//	err := ServeFS("/static/*file", assets)
+//
+// will serve "/static/css/app.css" with the "css/app.css" file. Use fs.Sub
+// to serve a sub-directory of the file system. Unlike parameter values, file
+// names are case-sensitive. Content type is detected from the file extension
+// or content, and directories are served with their index.html file or
+// a listing.
+func (r *Router) ServeFS(pattern string, fsys fs.FS) error {
+	return r.ServeFiles(pattern, http.FS(fsys))
+}
+
+// ServeFiles adds GET and HEAD handler that serves files from the file
+// system, like ServeFS does, for example:
+//
+//	err := ServeFiles("/media/*file", http.Dir("/var/media"))
+//
+// Range requests are supported, so that clients can download parts of
+// large files, like seeking in a video. Files are streamed without being
+// buffered.
+func (r *Router) ServeFiles(pattern string, fsys http.FileSystem) error {
+	// Check pattern.
+	pd, err := r.parsePattern(pattern)
+	if err != nil {
+		r.addError("GET", pattern, err)
+		return err
+	}
+
+	if !pd.catchAll() {
+		r.addError("GET", pattern, ErrFilesPattern)
+		return ErrFilesPattern
+	}
+
+	// Segments before the catch-all parameter are not part of the file name.
+	skip := len(pd.segments) - 1
+	files := http.FileServer(fsys)
+
+	return r.getAndHead(pattern, func(w http.ResponseWriter, req *http.Request, _ Params) {
+		// Take the file name from the request path to keep its case.
+		u := *req.URL
+		u.Path = filePath(req.URL.Path, skip)
+		u.RawPath = ""
+
+		r2 := *req
+		r2.URL = &u
+
+		files.ServeHTTP(w, &r2)
+	})
+}
+
+// filePath returns the request path without the first skip segments.
+// A trailing slash is kept.
+func filePath(p string, skip int) string {
+	segs := strings.Split(strings.TrimPrefix(cleanSlashes(p), "/"), "/")
+	if skip >= len(segs) {
+		return "/"
+	}
+
+	return "/" + strings.Join(segs[skip:], "/")
+}
+
+// getAndHead sets the handler for GET and HEAD methods, so that clients can
+// get the headers of the files, like size and modification time, without
+// the contents. http.ServeContent omits the body of HEAD responses. Both
+// handlers are set at once: if either cannot be set, for example because
+// the pattern already has a HEAD handler, the router is left unchanged.
+func (r *Router) getAndHead(pattern string, handler HandlerFunc) error {
+	method, err := r.addGetAndHead(pattern, handler)
+	if err != nil {
+		r.addError(method, pattern, err)
+	}
+
+	return err
+}
+
+// addGetAndHead adds the routes for Router.getAndHead to the same copy of
+// the route table. Returns the method of the route that could not be added.
+func (r *Router) addGetAndHead(pattern string, handler HandlerFunc) (string, error) {
+	// Parse pattern.
+	pd, err := r.parsePattern(pattern)
+	if err != nil {
+		return "GET", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.table.Load().clone()
+	for _, method := range []string{"GET", "HEAD"} {
+		if err := r.insertRoute(t, method, pd, newRoute(pattern, handler)); err != nil {
+			return method, err
+		}
+	}
+
+	r.table.Store(t)
+
+	return "", nil
+}
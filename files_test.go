@@ -0,0 +1,168 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestServeFile(t *testing.T) {
+	dir := t.TempDir()
+	robots := filepath.Join(dir, "robots.txt")
+	if err := os.WriteFile(robots, []byte("User-agent: *"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	index := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(index, []byte("<html></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := New()
+	rt.ServeFile("/robots.txt", robots)
+	rt.ServeFile("/index.html", index)
+	rt.ServeFile("/missing", filepath.Join(dir, "missing"))
+	rt.ServeFile("/dir", dir)
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"/robots.txt", http.StatusOK, "text/plain; charset=utf-8", "User-agent: *"},
+		{"/index.html", http.StatusOK, "text/html; charset=utf-8", "<html></html>"},
+		{"/missing", http.StatusNotFound, "", ""},
+		{"/dir", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+		if w.Code != tt.status || w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q %q, want %d %q %q", tt.path, w.Code, w.Header().Get("Content-Type"), w.Body.String(),
+				tt.status, tt.contentType, tt.body)
+		}
+	}
+}
+
+func TestServeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"CSS/App.css": {Data: []byte("body{}")},
+		"index.html":  {Data: []byte("<html></html>")},
+	}
+
+	rt := New()
+	if err := rt.ServeFS("/static", fsys); err != ErrFilesPattern {
+		t.Errorf("got %v without catch-all, want %v", err, ErrFilesPattern)
+	}
+
+	if err := rt.Build(); !errors.Is(err, ErrFilesPattern) {
+		t.Errorf("got %v from Build, want %v", err, ErrFilesPattern)
+	}
+
+	if err := rt.ServeFS("/static/*file", fsys); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		// File names keep their case.
+		{"/static/CSS/App.css", http.StatusOK, "text/css; charset=utf-8", "body{}"},
+		{"/static/", http.StatusOK, "text/html; charset=utf-8", "<html></html>"},
+		{"/static/missing.css", http.StatusNotFound, "text/plain; charset=utf-8", "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, "GET", tt.path)
+		if w.Code != tt.status || w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q %q, want %d %q %q", tt.path, w.Code, w.Header().Get("Content-Type"), w.Body.String(),
+				tt.status, tt.contentType, tt.body)
+		}
+	}
+}
+
+func TestServeFilesRange(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "video.txt"), []byte(strings.Repeat("0123456789", 300)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := New()
+	if err := rt.ServeFiles("/media/*file", http.Dir(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(rt, "GET", "/media/video.txt")
+	if w.Code != http.StatusOK || w.Header().Get("Accept-Ranges") != "bytes" || w.Body.Len() != 3000 {
+		t.Errorf("full: got %d %v with %d bytes", w.Code, w.Header(), w.Body.Len())
+	}
+
+	w = serve(rt, "GET", "/media/video.txt", "Range", "bytes=2-5")
+	if w.Code != http.StatusPartialContent || w.Header().Get("Content-Range") != "bytes 2-5/3000" || w.Body.String() != "2345" {
+		t.Errorf("range: got %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+}
+
+func TestServeFileHead(t *testing.T) {
+	dir := t.TempDir()
+	robots := filepath.Join(dir, "robots.txt")
+	if err := os.WriteFile(robots, []byte("User-agent: *"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := New()
+	rt.ServeFile("/robots.txt", robots)
+	rt.ServeFS("/static/*file", fstest.MapFS{"app.css": {Data: []byte("body{}"), ModTime: time.Unix(1e9, 0)}})
+
+	tests := []struct {
+		path          string
+		contentType   string
+		contentLength string
+	}{
+		{"/robots.txt", "text/plain; charset=utf-8", "13"},
+		{"/static/app.css", "text/css; charset=utf-8", "6"},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, "HEAD", tt.path)
+		if w.Code != http.StatusOK || w.Body.Len() != 0 {
+			t.Errorf("%s: got %d %q, want 200 without body", tt.path, w.Code, w.Body.String())
+		}
+
+		h := w.Header()
+		if h.Get("Content-Type") != tt.contentType || h.Get("Content-Length") != tt.contentLength || h.Get("Last-Modified") == "" {
+			t.Errorf("%s: got headers %v, want %q of %s bytes", tt.path, h, tt.contentType, tt.contentLength)
+		}
+	}
+
+	// The router is unchanged if the HEAD handler cannot be set.
+	rt.Handle("HEAD", "/taken", reply(""))
+	rt.HandleIf(func(r *http.Request) bool { return r.URL.Query().Has("v") }, "GET", "/taken", reply("predicate"))
+	if err := rt.ServeFile("/taken", robots); err != ErrDuplicateHandler {
+		t.Errorf("got %v, want %v", err, ErrDuplicateHandler)
+	}
+
+	if got := rt.AllowedMethods("/taken"); len(got) != 2 || got[0] != "GET" || got[1] != "HEAD" {
+		t.Errorf("got methods %q, want GET and HEAD", got)
+	}
+
+	if w := serve(rt, "GET", "/taken?v"); w.Code != http.StatusOK || w.Body.String() != "predicate" {
+		t.Errorf("got %d %q, want 200 %q", w.Code, w.Body.String(), "predicate")
+	}
+
+	if w := serve(rt, "GET", "/taken"); w.Code == http.StatusOK {
+		t.Errorf("got %d %q, want no file", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,51 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrForwardLoop is returned by Router.Forward if the request was already
+// forwarded too many times.
+var ErrForwardLoop error = errors.New("router: request was forwarded too many times")
+
+// Maximum number of times a request may be forwarded.
+const maxForwards = 10
+
+// forwardContextKey stores the number of times the request was forwarded.
+var forwardContextKey = &contextKey{"forward"}
+
+// Forward handles the request as if it was received with the method and
+// path, for example to serve the handler of another route without a
+// redirect:
+//
+//	err := r.Forward(w, req, "GET", "/v2/users")
+//
+// The query, headers, body and context values of the request are kept,
+// but the route it was matched with is forgotten, so parameters, the
+// matched pattern and the information about mounted routers come from the
+// new match only. The path is matched by this router from the start,
+// including PreRoute and router middleware. A request may be forwarded
+// up to 10 times, after that ErrForwardLoop is returned and nothing is
+// written.
+func (router *Router) Forward(w http.ResponseWriter, r *http.Request, method string, path string) error {
+	n, _ := r.Context().Value(forwardContextKey).(int)
+	if n >= maxForwards {
+		return ErrForwardLoop
+	}
+
+	// Forget the matched route.
+	ctx := context.WithValue(r.Context(), forwardContextKey, n+1)
+	for _, key := range []*contextKey{routeContextKey, paramsContextKey, ParamsContextKey, chainContextKey} {
+		ctx = context.WithValue(ctx, key, nil)
+	}
+
+	fr := r.Clone(ctx)
+	fr.Method = method
+	fr.URL.Path, fr.URL.RawPath = path, ""
+
+	router.doServeHTTP(w, fr)
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestForward(t *testing.T) {
+	var loopErr error
+
+	rt := New()
+	rt.Get("/v2/users/:id", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		fmt.Fprintf(w, "%s %s %v", r.Method, MatchedPattern(r), map[string][]string(ps))
+	})
+	rt.Post("/v1/users/:old", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		if err := rt.Forward(w, r, "GET", "/v2/users/7"); err != nil {
+			t.Error(err)
+		}
+	})
+	rt.Get("/loop", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		if err := rt.Forward(w, r, "GET", "/loop"); err != nil {
+			loopErr = err
+			w.WriteHeader(http.StatusLoopDetected)
+		}
+	})
+
+	// Parameters of the original route are forgotten, the query is kept.
+	want := "GET /v2/users/:id map[id:[7] q:[1]]"
+	if w := serve(rt, "POST", "/v1/users/x?q=1"); w.Body.String() != want {
+		t.Errorf("got %q, want %q", w.Body.String(), want)
+	}
+
+	if w := serve(rt, "GET", "/loop"); w.Code != http.StatusLoopDetected || loopErr != ErrForwardLoop {
+		t.Errorf("got %d with %v, want 508 with %v", w.Code, loopErr, ErrForwardLoop)
+	}
+}
@@ -0,0 +1,229 @@
+package router
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Default minimum size of response body in bytes that will be compressed
+// by Gzip middleware.
+const defaultGzipMinSize = 1024
+
+// Content types that are already compressed and would not benefit from gzip.
+var compressedTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-bzip2",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// Gzip returns middleware that compresses response bodies with gzip for
+// clients that send "Accept-Encoding: gzip". Responses smaller than 1024
+// bytes and responses with already compressed content types are sent as is.
+func Gzip() Middleware {
+	return GzipWithMinSize(defaultGzipMinSize)
+}
+
+// GzipWithMinSize works like Gzip, but compresses only responses with body
+// of at least minSize bytes.
+func GzipWithMinSize(minSize int) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, ps Params) {
+			// Response depends on Accept-Encoding header in any case.
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			// Check if client accepts gzip.
+			if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				h(w, r, ps)
+				return
+			}
+
+			// Call the request handler with compressing writer.
+			gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize}
+			h(gw, r, ps)
+
+			// Write the rest of the response.
+			gw.close()
+		}
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header value allows
+// gzip encoding.
+func acceptsGzip(header string) bool {
+	return encodingQuality(parseAccept(header), "gzip") > 0
+}
+
+// gzipResponseWriter buffers the beginning of the response until it is known
+// whether the response should be compressed.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+	status  int
+	buf     []byte
+	gz      *gzip.Writer
+	decided bool
+}
+
+// WriteHeader remembers the status code. The header is sent only when the
+// encoding of the response is decided.
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers data until minimal size is reached and writes it compressed
+// or as is afterwards.
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.decided {
+		// Keep buffering until there is enough data to decide.
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+
+		return len(b), nil
+	}
+
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client. A streaming response is
+// compressed regardless of its current size.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+
+		w.decide(true)
+	}
+
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection if the underlying
+// writer supports it. Data written before is discarded, as nothing is
+// written to the hijacked connection by the middleware.
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := hijack(w.ResponseWriter)
+	if err == nil {
+		w.decided = true
+		w.buf = nil
+	}
+
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header and the buffered data, compressing it if
+// compress is true and the content type is not already compressed.
+func (w *gzipResponseWriter) decide(compress bool) error {
+	w.decided = true
+
+	h := w.ResponseWriter.Header()
+
+	// Detect content type of the response, like http.ResponseWriter does.
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	// Parts of the content cannot be compressed separately.
+	if w.status == http.StatusPartialContent || h.Get("Content-Range") != "" {
+		compress = false
+	}
+
+	if compress && h.Get("Content-Encoding") == "" && !isCompressedType(h.Get("Content-Type")) {
+		// Length of compressed response is unknown.
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	// Write buffered data.
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+
+	w.buf = nil
+
+	return err
+}
+
+// close writes remaining data after the handler has finished.
+func (w *gzipResponseWriter) close() {
+	if !w.decided {
+		// Response is too small to be compressed.
+		w.decide(false)
+	}
+
+	if w.gz != nil {
+		w.gz.Close()
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// isCompressedType reports whether content type is already compressed.
+func isCompressedType(ct string) bool {
+	ct = mediaType(ct)
+	for _, t := range compressedTypes {
+		if strings.HasPrefix(ct, t) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// gunzip returns decompressed body of the response.
+func gunzip(t *testing.T, r io.Reader) string {
+	t.Helper()
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestGzip(t *testing.T) {
+	big := strings.Repeat("hello ", 500)
+
+	rt := New()
+	rt.Use(Gzip())
+	rt.Get("/big", reply(big))
+	rt.Get("/small", reply("hi"))
+	rt.Get("/image", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(big))
+	})
+
+	// Large responses are compressed for clients that accept gzip.
+	w := serve(rt, "GET", "/big", "Accept-Encoding", "gzip, deflate")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" {
+		t.Fatalf("got headers %v, want gzip encoding without length", w.Header())
+	}
+
+	if body := gunzip(t, w.Body); body != big {
+		t.Errorf("got decompressed body of %d bytes, want %d", len(body), len(big))
+	}
+
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("got Vary %q, want Accept-Encoding", w.Header().Get("Vary"))
+	}
+
+	tests := []struct {
+		path     string
+		encoding string
+	}{
+		{"/big", ""},
+		{"/big", "gzip;q=0"},
+		{"/small", "gzip"},
+		{"/image", "gzip"},
+	}
+
+	// Other responses are sent as is.
+	for _, tt := range tests {
+		w := serve(rt, "GET", tt.path, "Accept-Encoding", tt.encoding)
+		if w.Header().Get("Content-Encoding") != "" || w.Code != http.StatusOK {
+			t.Errorf("%s with %q: got %d %v, want uncompressed", tt.path, tt.encoding, w.Code, w.Header())
+		}
+	}
+}
+
+func TestGzipMinSize(t *testing.T) {
+	rt := New()
+	rt.Get("/:n", GzipWithMinSize(10)(func(w http.ResponseWriter, r *http.Request, ps Params) {
+		n, _ := ps.GetInt("n")
+		w.Write([]byte(strings.Repeat("a", n)))
+	}))
+
+	for n, compressed := range map[string]bool{"9": false, "10": true, "100": true} {
+		w := serve(rt, "GET", "/"+n, "Accept-Encoding", "gzip")
+		if got := w.Header().Get("Content-Encoding") == "gzip"; got != compressed {
+			t.Errorf("%s bytes: got compressed %v, want %v", n, got, compressed)
+		}
+	}
+}
+
+func TestGzipFlush(t *testing.T) {
+	rt := New()
+	rt.Get("/stream", Gzip()(func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Write([]byte("a"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("b"))
+	}))
+
+	// A flushed response is compressed even if it is small.
+	w := serve(rt, "GET", "/stream", "Accept-Encoding", "gzip")
+	if !w.Flushed || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("got flushed %v, headers %v", w.Flushed, w.Header())
+	}
+
+	if body := gunzip(t, w.Body); body != "ab" {
+		t.Errorf("got body %q, want %q", body, "ab")
+	}
+}
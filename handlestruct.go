@@ -0,0 +1,127 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Methods that HandleStruct recognizes in the names of handler methods,
+// in the form they start the names.
+var structMethods = []string{"Get", "Head", "Post", "Put", "Patch", "Delete", "Options"}
+
+// HandleStruct registers exported methods of obj with the signature of
+// HandlerFunc as handlers by their names, for example:
+//
+//	type Users struct{}
+//
+//	func (Users) Get(w http.ResponseWriter, r *http.Request, ps Params)       {}
+//	func (Users) GetByID(w http.ResponseWriter, r *http.Request, ps Params)   {}
+//	func (Users) PostAvatar(w http.ResponseWriter, r *http.Request, ps Params) {}
+//
+//	err := HandleStruct("/users", Users{})
+//
+// registers "GET /users", "GET /users/:id" and "POST /users/avatar". The name
+// of a method starts with an HTTP method: Get, Head, Post, Put, Patch, Delete
+// or Options. The rest of the name is split into words at upper case
+// letters, keeping acronyms like "ID" together, and every word becomes a
+// path segment in lower case, except that "By" makes the following word
+// a named parameter, written with the marker set by ParamPrefix, for
+// example "/users/{id}" for "{". Methods with other names or signatures
+// are ignored.
+// Like Register, it does not stop on the first error, and errors of all
+// failed routes are returned as RegistrationErrors.
+func (r *Router) HandleStruct(prefix string, obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	t := v.Type()
+
+	open, close := r.paramMarkers()
+
+	var routes []Route
+	for i := 0; i < t.NumMethod(); i++ {
+		h, ok := v.Method(i).Interface().(func(http.ResponseWriter, *http.Request, Params))
+		if !ok {
+			continue
+		}
+
+		method, pattern, ok := structRoute(t.Method(i).Name, open, close)
+		if !ok {
+			continue
+		}
+
+		routes = append(routes, Route{Method: method, Pattern: joinPattern(prefix, pattern), Handler: h})
+	}
+
+	return r.Register(routes)
+}
+
+// structRoute returns the method and the pattern of the route for the name
+// of a handler method, with named parameters between the markers.
+func structRoute(name string, open string, close string) (string, string, bool) {
+	for _, m := range structMethods {
+		rest, ok := strings.CutPrefix(name, m)
+		if !ok || rest != "" && !unicode.IsUpper(rune(rest[0])) {
+			continue
+		}
+
+		var b strings.Builder
+		param := false
+		for _, w := range splitWords(rest) {
+			if w == "By" && !param {
+				param = true
+				continue
+			}
+
+			b.WriteString("/")
+			if param {
+				b.WriteString(open + strings.ToLower(w) + close)
+				param = false
+				continue
+			}
+
+			b.WriteString(strings.ToLower(w))
+		}
+
+		// Trailing "By" is a path segment.
+		if param {
+			b.WriteString("/by")
+		}
+
+		if b.Len() == 0 {
+			return strings.ToUpper(m), "/", true
+		}
+
+		return strings.ToUpper(m), b.String(), true
+	}
+
+	return "", "", false
+}
+
+// splitWords splits the camel case name into words. A run of upper case
+// letters is one word, except for the last letter if it starts a word in
+// lower case.
+func splitWords(name string) []string {
+	rs := []rune(name)
+
+	var words []string
+	start := 0
+	for i := 1; i < len(rs); i++ {
+		if !unicode.IsUpper(rs[i]) {
+			continue
+		}
+
+		// Word starts after lower case letter or before lower case letter
+		// that follows an acronym.
+		if !unicode.IsUpper(rs[i-1]) || i+1 < len(rs) && unicode.IsLower(rs[i+1]) {
+			words = append(words, string(rs[start:i]))
+			start = i
+		}
+	}
+
+	if start < len(rs) {
+		words = append(words, string(rs[start:]))
+	}
+
+	return words
+}
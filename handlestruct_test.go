@@ -0,0 +1,87 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// users is a handler struct of HandleStruct.
+type users struct{}
+
+func (users) Get(w http.ResponseWriter, r *http.Request, ps Params) {
+	w.Write([]byte("list"))
+}
+
+func (users) GetByID(w http.ResponseWriter, r *http.Request, ps Params) {
+	id, _ := ps.Get("id")
+	w.Write([]byte("user " + id))
+}
+
+func (users) PostAvatar(w http.ResponseWriter, r *http.Request, ps Params) {
+	w.Write([]byte("avatar"))
+}
+
+func (users) GetHTMLPageByName(w http.ResponseWriter, r *http.Request, ps Params) {
+	name, _ := ps.Get("name")
+	w.Write([]byte("page " + name))
+}
+
+// Methods with other names or signatures are ignored.
+func (users) Getter() string                                                  { return "" }
+func (users) Helper(w http.ResponseWriter, r *http.Request, ps Params)        {}
+func (users) Delete(w http.ResponseWriter, r *http.Request, ps Params, n int) {}
+
+func TestHandleStruct(t *testing.T) {
+	rt := New()
+	if err := rt.HandleStruct("/users", users{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var routes []string
+	for _, ri := range rt.Routes() {
+		routes = append(routes, ri.Method+" "+ri.Pattern)
+	}
+
+	want := []string{"GET /users", "GET /users/:id", "POST /users/avatar", "GET /users/html/page/:name"}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("got routes %q, want %q", routes, want)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/users", "list"},
+		{"GET", "/users/7", "user 7"},
+		{"POST", "/users/avatar", "avatar"},
+		{"GET", "/users/html/page/about", "page about"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, tt.method, tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s %s: got %d %q, want %q", tt.method, tt.path, w.Code, w.Body.String(), tt.body)
+		}
+	}
+}
+
+// accounts is a handler struct for routers with other parameter markers.
+type accounts struct{}
+
+func (accounts) GetUsersById(w http.ResponseWriter, r *http.Request, ps Params) {
+	id, _ := ps.Get("id")
+	w.Write([]byte("user " + id))
+}
+
+func TestHandleStructParamPrefix(t *testing.T) {
+	rt := New()
+	rt.ParamPrefix = "{"
+	if err := rt.HandleStruct("/api", accounts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := serve(rt, "GET", "/api/users/7"); w.Body.String() != "user 7" {
+		t.Errorf("got %d %q, want %q", w.Code, w.Body.String(), "user 7")
+	}
+}
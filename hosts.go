@@ -0,0 +1,196 @@
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Host returns the router for requests to the host, creating it if needed,
+// for example:
+//
+//	err := Host("api.example.com").Get("/users", usersHandler)
+//
+// Host names are case-insensitive, and the port of the request host is
+// ignored. Requests to the host are handled by its router if it has a route
+// matching the request path, otherwise they are handled by the routes of
+// this router. Middleware of this router wraps handling by host routers too.
+// Walk and Summary do not include routes of host routers.
+//
+// The first label of the host may be a named parameter, that matches any
+// single label, for example:
+//
+//	tenants := Host(":tenant.app.com")
+//
+// matches "acme.app.com" with tenant "acme", but does not match "app.com"
+// or "eu.acme.app.com". The parameter is added to Params of the host router
+// handlers like parameters of a mount pattern. Hosts without parameters win
+// over hosts with parameters. Patterns that differ only in the parameter
+// name match the same hosts and are tried in lexical order, so that the
+// order in which they are added does not matter.
+func (r *Router) Host(host string) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Find the router of the host with parameter. The leading colon would
+	// be taken for a port by hostName.
+	if strings.HasPrefix(host, ":") {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		for _, hp := range r.loadConfig().hostPatterns {
+			if hp.pattern == host {
+				return hp.router
+			}
+		}
+
+		name, suffix, _ := strings.Cut(host[1:], ".")
+		hp := &hostPattern{pattern: host, param: name, suffix: "." + suffix, router: New()}
+
+		c := r.loadConfig().clone()
+		c.hostPatterns = append(c.hostPatterns, hp)
+		sort.Slice(c.hostPatterns, func(i, j int) bool {
+			return c.hostPatterns[i].pattern < c.hostPatterns[j].pattern
+		})
+		r.config.Store(c)
+
+		return hp.router
+	}
+
+	host = hostName(host)
+	hr, ok := r.loadConfig().hosts[host]
+	if !ok {
+		hr = New()
+
+		c := r.loadConfig().clone()
+		c.hosts[host] = hr
+		r.config.Store(c)
+	}
+
+	return hr
+}
+
+// hostPattern is a host with a named parameter in the first label.
+type hostPattern struct {
+	pattern string
+	param   string
+	suffix  string
+	router  *Router
+}
+
+// match reports whether the host matches the pattern and returns the value
+// of the parameter.
+func (hp *hostPattern) match(host string) (string, bool) {
+	v, ok := strings.CutSuffix(host, hp.suffix)
+	if !ok || v == "" || strings.Contains(v, ".") {
+		return "", false
+	}
+
+	return v, true
+}
+
+// Hosts returns a group of host routers to register the same routes for
+// several hosts at once, for example:
+//
+//	err := Hosts("example.com", "www.example.com").Get("/", indexHandler)
+//
+// Routes are added to the router of every host, as returned by Host.
+func (r *Router) Hosts(hosts ...string) *HostGroup {
+	g := &HostGroup{}
+	for _, h := range hosts {
+		g.routers = append(g.routers, r.Host(h))
+	}
+
+	return g
+}
+
+// A HostGroup registers routes for several hosts. It is returned by
+// Router.Hosts.
+type HostGroup struct {
+	routers []*Router
+}
+
+// Handle sets an HTTP request handler for specific method and pattern for
+// every host of the group, like Router.Handle does. If the route cannot be
+// registered for some host, it is still registered for the others, and the
+// first error is returned.
+func (g *HostGroup) Handle(method string, pattern string, handler HandlerFunc) error {
+	return g.HandleWith(method, pattern, handler)
+}
+
+// HandleWith is like Handle, but additionally applies route options, like
+// Router.HandleWith does.
+func (g *HostGroup) HandleWith(method string, pattern string, handler HandlerFunc, opts ...RouteOption) error {
+	var first error
+	for _, hr := range g.routers {
+		if err := hr.HandleWith(method, pattern, handler, opts...); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// Get adds handler for GET request.
+func (g *HostGroup) Get(pattern string, handler HandlerFunc) error {
+	return g.Handle("GET", pattern, handler)
+}
+
+// Put adds handler for PUT request.
+func (g *HostGroup) Put(pattern string, handler HandlerFunc) error {
+	return g.Handle("PUT", pattern, handler)
+}
+
+// Post adds handler for POST request.
+func (g *HostGroup) Post(pattern string, handler HandlerFunc) error {
+	return g.Handle("POST", pattern, handler)
+}
+
+// Delete adds handler for DELETE request.
+func (g *HostGroup) Delete(pattern string, handler HandlerFunc) error {
+	return g.Handle("DELETE", pattern, handler)
+}
+
+// hostRouter returns the router of the request host if it has a route that
+// matches the request path, and the parameters captured from the host.
+func (router *Router) hostRouter(r *http.Request) (*Router, Params) {
+	host := hostName(r.Host)
+
+	// Host without parameters wins.
+	c := router.loadConfig()
+	if hr := c.hosts[host]; hr != nil && hr.lookup(r).pd != nil {
+		return hr, Params{}
+	}
+
+	for _, hp := range c.hostPatterns {
+		if v, ok := hp.match(host); ok && hp.router.lookup(r).pd != nil {
+			return hp.router, Params{hp.param: {v}}
+		}
+	}
+
+	return nil, nil
+}
+
+// hostName returns lower case host name without the port.
+func hostName(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// serveHost passes the request to the host router with the parameters
+// captured from the host.
+func (router *Router) serveHost(w http.ResponseWriter, r *http.Request, hr *Router, ps Params) {
+	// Make parameters captured from the host and by the routers this one is
+	// mounted to available for the host router.
+	if len(ps) > 0 {
+		c := Params{}
+		c.merge(uriParams(r))
+		c.merge(ps)
+		r = r.WithContext(context.WithValue(r.Context(), paramsContextKey, c))
+	}
+
+	hr.ServeHTTP(w, r)
+}
@@ -0,0 +1,89 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveHost sends GET request with the host to the router and returns the
+// response body.
+func serveHost(rt *Router, host string, path string) string {
+	req := httptest.NewRequest("GET", path, nil)
+	req.Host = host
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	return w.Body.String()
+}
+
+func TestHosts(t *testing.T) {
+	rt := New()
+	rt.Get("/", reply("default"))
+	rt.Get("/about", reply("default about"))
+
+	if err := rt.Hosts("example.com", "WWW.example.com").Get("/", reply("example")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rt.Hosts("example.com").Get("/", reply("again")); err != ErrDuplicateHandler {
+		t.Errorf("got %v, want %v", err, ErrDuplicateHandler)
+	}
+
+	tests := []struct {
+		host string
+		path string
+		body string
+	}{
+		{"example.com", "/", "example"},
+		{"www.example.com:8080", "/", "example"},
+		{"EXAMPLE.COM", "/", "example"},
+
+		// Other hosts and paths not registered for the host fall back.
+		{"other.com", "/", "default"},
+		{"example.com", "/about", "default about"},
+	}
+
+	for _, tt := range tests {
+		if got := serveHost(rt, tt.host, tt.path); got != tt.body {
+			t.Errorf("%s%s: got %q, want %q", tt.host, tt.path, got, tt.body)
+		}
+	}
+}
+
+func TestHostParam(t *testing.T) {
+	rt := New()
+	rt.Get("/", reply("default"))
+	rt.Host(":tenant.app.com").Get("/", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		v, _ := ps.Get("tenant")
+		w.Write([]byte("tenant=" + v))
+	})
+	rt.Host("www.app.com").Get("/", reply("www"))
+
+	if rt.Host(":tenant.app.com") != rt.Host(":TENANT.app.com") {
+		t.Error("host pattern router created twice")
+	}
+
+	tests := []struct {
+		host string
+		body string
+	}{
+		{"acme.app.com", "tenant=acme"},
+		{"Acme.App.com:8080", "tenant=acme"},
+
+		// Host without parameters wins.
+		{"www.app.com", "www"},
+
+		// Parameter matches a single label.
+		{"app.com", "default"},
+		{"eu.acme.app.com", "default"},
+		{"acme.other.com", "default"},
+	}
+
+	for _, tt := range tests {
+		if got := serveHost(rt, tt.host, "/"); got != tt.body {
+			t.Errorf("%s: got %q, want %q", tt.host, got, tt.body)
+		}
+	}
+}
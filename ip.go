@@ -0,0 +1,134 @@
+package router
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrIPAddress is returned by RestrictIP and DenyIP if an address or
+// a network in the list cannot be parsed.
+var ErrIPAddress error = errors.New("router: invalid IP address or CIDR network")
+
+// An IPOption configures middleware returned by RestrictIP and DenyIP.
+type IPOption func(*ipFilter)
+
+type ipFilter struct {
+	nets           []*net.IPNet
+	allow          bool
+	trustForwarded bool
+}
+
+// TrustForwardedFor makes IP filtering middleware read the client address
+// from the X-Forwarded-For header instead of the request remote address.
+// The last address in the header is used, which is the one added by the
+// nearest proxy. Use it only when the router runs behind a trusted proxy.
+func TrustForwardedFor() IPOption {
+	return func(f *ipFilter) {
+		f.trustForwarded = true
+	}
+}
+
+// RestrictIP returns middleware that responds with 403 Forbidden to the
+// clients with IP addresses outside of the allow list. The list may contain
+// both single addresses ("10.0.0.1") and CIDR networks ("10.0.0.0/8").
+func RestrictIP(allow []string, opts ...IPOption) (Middleware, error) {
+	return newIPFilter(allow, true, opts)
+}
+
+// DenyIP returns middleware that responds with 403 Forbidden to the clients
+// with IP addresses from the deny list. The list has the same format as for
+// RestrictIP.
+func DenyIP(deny []string, opts ...IPOption) (Middleware, error) {
+	return newIPFilter(deny, false, opts)
+}
+
+func newIPFilter(list []string, allow bool, opts []IPOption) (Middleware, error) {
+	f := &ipFilter{allow: allow}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	// Parse networks once.
+	for _, s := range list {
+		n, err := parseIPNet(s)
+		if err != nil {
+			return nil, err
+		}
+
+		f.nets = append(f.nets, n)
+	}
+
+	return f.middleware, nil
+}
+
+// parseIPNet parses CIDR network or single IP address.
+func parseIPNet(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, ErrIPAddress
+		}
+
+		return n, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, ErrIPAddress
+	}
+
+	// Single address is a network with all bits set in the mask.
+	bits := 8 * net.IPv6len
+	if v4 := ip.To4(); v4 != nil {
+		ip, bits = v4, 8*net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func (f *ipFilter) middleware(h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, ps Params) {
+		if !f.permits(f.clientIP(r)) {
+			// Set status code to 403 Forbidden.
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		h(w, r, ps)
+	}
+}
+
+// clientIP returns IP address of the client or nil if it is unknown.
+func (f *ipFilter) clientIP(r *http.Request) net.IP {
+	if f.trustForwarded {
+		if v := r.Header.Get("X-Forwarded-For"); v != "" {
+			parts := strings.Split(v, ",")
+			return net.ParseIP(strings.TrimSpace(parts[len(parts)-1]))
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return net.ParseIP(host)
+}
+
+// permits reports whether the client with the address is allowed.
+// Clients with unknown address are denied.
+func (f *ipFilter) permits(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range f.nets {
+		if n.Contains(ip) {
+			return f.allow
+		}
+	}
+
+	return !f.allow
+}
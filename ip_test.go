@@ -0,0 +1,65 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilter(t *testing.T) {
+	allow, err := RestrictIP([]string{"10.0.0.0/8", "192.168.1.5", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deny, err := DenyIP([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forwarded, err := RestrictIP([]string{"10.0.0.0/8"}, TrustForwardedFor())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RestrictIP([]string{"10.0.0.0/40"}); err != ErrIPAddress {
+		t.Errorf("got %v for invalid network, want %v", err, ErrIPAddress)
+	}
+
+	rt := New()
+	rt.Get("/allow", allow(reply("ok")))
+	rt.Get("/deny", deny(reply("ok")))
+	rt.Get("/forwarded", forwarded(reply("ok")))
+
+	tests := []struct {
+		path      string
+		addr      string
+		forwarded string
+		status    int
+	}{
+		{"/allow", "10.1.2.3:5000", "", 200},
+		{"/allow", "192.168.1.5:5000", "", 200},
+		{"/allow", "192.168.1.6:5000", "", 403},
+		{"/allow", "[::1]:5000", "", 200},
+		{"/deny", "10.1.2.3:5000", "", 403},
+		{"/deny", "192.168.1.6:5000", "", 200},
+
+		// The last address is added by the nearest proxy.
+		{"/forwarded", "1.1.1.1:5000", "2.2.2.2, 10.0.0.1", 200},
+		{"/forwarded", "10.0.0.1:5000", "2.2.2.2", 403},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.RemoteAddr = tt.addr
+		if tt.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s from %s (%q): got %d, want %d", tt.path, tt.addr, tt.forwarded, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// JSON writes the value encoded as JSON as the response body with the
+// status code, for example:
+//
+//	err := JSON(w, http.StatusCreated, user)
+//
+// Content type is set to "application/json; charset=utf-8". The header is
+// written before encoding, so if the value cannot be encoded, the error is
+// returned, but the status code is already sent.
+func JSON(w http.ResponseWriter, status int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	return json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,31 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := JSON(w, http.StatusCreated, map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusCreated || w.Header().Get("Content-Type") != "application/json; charset=utf-8" || w.Body.String() != "{\"id\":1}\n" {
+		t.Errorf("got %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+
+	// The status is sent even if the value cannot be encoded.
+	w = httptest.NewRecorder()
+	var typeErr *json.UnsupportedTypeError
+	if err := JSON(w, http.StatusOK, make(chan int)); !errors.As(err, &typeErr) {
+		t.Errorf("got %v, want *json.UnsupportedTypeError", err)
+	}
+
+	if w.Code != http.StatusOK {
+		t.Errorf("got %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrNoLanguages is returned by HandleLanguages if no handlers are passed.
+var ErrNoLanguages error = errors.New("router: at least one language handler is required")
+
+// A LanguageHandler is a handler that serves a response in the language,
+// identified by a tag like "en" or "de-CH".
+type LanguageHandler struct {
+	Language string
+	Handler  HandlerFunc
+}
+
+// HandleLanguages sets handlers of localized response variants for
+// specific method and pattern, for example:
+//
+//	err := HandleLanguages("GET", "/about",
+//		LanguageHandler{"en", aboutHandler},
+//		LanguageHandler{"de", aboutGermanHandler})
+//
+// The handler is chosen by the Accept-Language request header: the variant
+// with the highest quality wins, and when qualities are equal the one listed
+// first wins. A language range matches the tag of a variant if it is equal
+// to the tag or to its prefix followed by "-", so "en" matches "en-GB", and
+// "*" matches any tag. If no variant is accepted, the first handler is used
+// as the default.
+//
+// The router sets "Vary: Accept-Language" header for all responses and
+// Content-Language header with the language of the chosen variant.
+func (r *Router) HandleLanguages(method string, pattern string, handlers ...LanguageHandler) error {
+	if len(handlers) == 0 {
+		r.addError(method, pattern, ErrNoLanguages)
+		return ErrNoLanguages
+	}
+
+	return r.Handle(method, pattern, func(w http.ResponseWriter, req *http.Request, ps Params) {
+		w.Header().Add("Vary", "Accept-Language")
+
+		// Choose variant.
+		lh := selectLanguage(handlers, req.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Language", lh.Language)
+
+		lh.Handler(w, req, ps)
+	})
+}
+
+// selectLanguage returns the most preferred of the accepted variants, or
+// the first variant if none is accepted.
+func selectLanguage(handlers []LanguageHandler, header string) *LanguageHandler {
+	accepts := parseAccept(header)
+
+	best := &handlers[0]
+	bestQ := 0.0
+	for i := range handlers {
+		lh := &handlers[i]
+		if q := languageQuality(accepts, lh.Language); q > bestQ {
+			best, bestQ = lh, q
+		}
+	}
+
+	return best
+}
+
+// languageQuality returns quality of the language tag in the parsed
+// Accept-Language header. The longest matching range is used.
+func languageQuality(accepts []acceptValue, tag string) float64 {
+	tag = strings.ToLower(tag)
+
+	q, n := 0.0, -1
+	for _, a := range accepts {
+		switch {
+		case a.value == "*":
+			// Wildcard applies if the tag is not matched by other ranges.
+			if n < 0 {
+				q, n = a.q, 0
+			}
+		case a.value == tag || strings.HasPrefix(tag, a.value+"-"):
+			if len(a.value) > n {
+				q, n = a.q, len(a.value)
+			}
+		}
+	}
+
+	return q
+}
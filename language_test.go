@@ -0,0 +1,50 @@
+package router
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHandleLanguages(t *testing.T) {
+	rt := New()
+	if err := rt.HandleLanguages("GET", "/none"); err != ErrNoLanguages {
+		t.Errorf("got %v, want %v", err, ErrNoLanguages)
+	}
+
+	if err := rt.Build(); !errors.Is(err, ErrNoLanguages) {
+		t.Errorf("got %v from Build, want %v", err, ErrNoLanguages)
+	}
+
+	rt.HandleLanguages("GET", "/about",
+		LanguageHandler{"en", reply("en")},
+		LanguageHandler{"de-CH", reply("de")},
+		LanguageHandler{"fr", reply("fr")})
+
+	tests := []struct {
+		accept string
+		body   string
+	}{
+		{"de", "de"},
+		{"de-ch, fr", "de"},
+		{"fr;q=0.5, de;q=0.8", "de"},
+		{"*;q=0.1, fr;q=0.2", "fr"},
+		{"en;q=0, *", "de"},
+
+		// The first variant is the default.
+		{"", "en"},
+		{"ja", "en"},
+		{"fr-CA", "en"},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, "GET", "/about", "Accept-Language", tt.accept)
+		if w.Body.String() != tt.body || w.Header().Get("Content-Language") == "" {
+			t.Errorf("%q: got %q in %q, want %q", tt.accept, w.Body.String(),
+				w.Header().Get("Content-Language"), tt.body)
+		}
+
+		if w.Header().Get("Vary") != "Accept-Language" {
+			t.Errorf("%q: got Vary %q, want Accept-Language", tt.accept, w.Header().Get("Vary"))
+		}
+	}
+}
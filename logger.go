@@ -0,0 +1,99 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Fields of the log line written by Logger middleware.
+const (
+	LogMethod   = "method"
+	LogPath     = "path"
+	LogPattern  = "pattern"
+	LogStatus   = "status"
+	LogBytes    = "bytes"
+	LogDuration = "duration"
+)
+
+// LoggerOptions configures Logger middleware.
+type LoggerOptions struct {
+	// Writer receives one line with space separated key=value pairs per
+	// request. It is used if Logger is nil. If both are nil, os.Stderr is
+	// used.
+	Writer io.Writer
+
+	// Logger receives one info record per request with fields as
+	// attributes.
+	Logger *slog.Logger
+
+	// Fields lists fields to log in the order they are written. All fields
+	// are logged if it is empty.
+	Fields []string
+}
+
+var defaultLogFields = []string{LogMethod, LogPath, LogPattern, LogStatus, LogBytes, LogDuration}
+
+// Logger returns middleware that logs every request with its method, path,
+// matched pattern, response status, number of body bytes written and
+// duration of the request handling.
+func Logger(opts LoggerOptions) Middleware {
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = defaultLogFields
+	}
+
+	out := opts.Writer
+	if out == nil {
+		out = os.Stderr
+	}
+
+	return func(h HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, ps Params) {
+			start := time.Now()
+
+			// Call the request handler with writer that records the status.
+			rw := &responseWriter{ResponseWriter: w}
+			h(rw, r, ps)
+
+			d := time.Since(start)
+
+			// Collect field values.
+			attrs := make([]slog.Attr, 0, len(fields))
+			for _, f := range fields {
+				switch f {
+				case LogMethod:
+					attrs = append(attrs, slog.String(f, r.Method))
+				case LogPath:
+					attrs = append(attrs, slog.String(f, r.URL.Path))
+				case LogPattern:
+					attrs = append(attrs, slog.String(f, MatchedPattern(r)))
+				case LogStatus:
+					attrs = append(attrs, slog.Int(f, rw.Status()))
+				case LogBytes:
+					attrs = append(attrs, slog.Int64(f, rw.bytes))
+				case LogDuration:
+					attrs = append(attrs, slog.Duration(f, d))
+				}
+			}
+
+			// Write structured record if logger is set.
+			if opts.Logger != nil {
+				opts.Logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+				return
+			}
+
+			// Write text line otherwise.
+			parts := make([]string, len(attrs))
+			for i, a := range attrs {
+				parts[i] = a.Key + "=" + a.Value.String()
+			}
+
+			fmt.Fprintln(out, strings.Join(parts, " "))
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	rt := New()
+	rt.Use(Logger(LoggerOptions{Writer: &buf}))
+	rt.Get("/users/:id", reply("hello"))
+
+	serve(rt, "GET", "/users/5")
+
+	line := regexp.MustCompile(`^method=GET path=/users/5 pattern=/users/:id status=200 bytes=5 duration=\S+\n$`)
+	if !line.MatchString(buf.String()) {
+		t.Errorf("got log line %q", buf.String())
+	}
+}
+
+func TestLoggerRecord(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	rt := New()
+	rt.Use(Logger(LoggerOptions{Logger: logger, Fields: []string{LogPattern, LogStatus, LogBytes}}))
+	rt.Get("/users/:id", reply("hello"))
+
+	serve(rt, "GET", "/missing")
+	serve(rt, "GET", "/users/5")
+
+	var record struct {
+		Pattern string
+		Status  int
+		Bytes   int
+	}
+
+	// Only matched requests pass through the router middleware.
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+
+	if record.Pattern != "/users/:id" || record.Status != 200 || record.Bytes != 5 {
+		t.Errorf("got record %+v", record)
+	}
+}
+
+func TestFlushPassthrough(t *testing.T) {
+	events := func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Errorf("%T is not http.Flusher", w)
+			return
+		}
+
+		f.Flush()
+	}
+
+	var buf bytes.Buffer
+	rt := New()
+	rt.Use(Logger(LoggerOptions{Writer: &buf}), Gzip())
+	rt.Get("/events", events)
+	rt.HandleChain("GET", "/chain", events)
+
+	for _, path := range []string{"/events", "/chain"} {
+		if w := serve(rt, "GET", path, "Accept-Encoding", "gzip"); !w.Flushed {
+			t.Errorf("%s: not flushed", path)
+		}
+	}
+}
+
+func TestHijack(t *testing.T) {
+	rt := New()
+	rt.Use(Logger(LoggerOptions{Writer: io.Discard}), Gzip())
+	rt.Get("/ws", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			t.Errorf("%T is not http.Hijacker", w)
+			return
+		}
+
+		conn, rw, err := h.Hijack()
+		if errors.Is(err, http.ErrNotSupported) {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		} else if err != nil {
+			t.Error(err)
+			return
+		}
+
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+	})
+
+	srv := httptest.NewServer(rt)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("got %d, want 101", res.StatusCode)
+	}
+
+	// Writers that cannot hijack report it.
+	if w := serve(rt, "GET", "/ws"); w.Code != http.StatusNotImplemented {
+		t.Errorf("got %d from recorder, want 501", w.Code)
+	}
+}
@@ -0,0 +1,197 @@
+package router
+
+import (
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// middlewareEntry is a middleware registered with Router.Use, Router.UseFor
+// or WithMiddleware. Nil methods mean that middleware applies to all
+// methods. Nil when means that middleware applies to all paths.
+type middlewareEntry struct {
+	name    string
+	methods []string
+	when    func(path string) bool
+	mw      Middleware
+}
+
+// Use adds middleware that wraps handlers of all routes, including requests
+// passed to mounted routers. Middleware is applied in the order it was added,
+// so the first one runs first.
+func (r *Router) Use(mw ...Middleware) {
+	r.UseFor(nil, mw...)
+}
+
+// UseFor adds middleware that wraps handlers only for requests with one of
+// the specified methods, for example:
+//
+//	UseFor([]string{"POST", "PUT", "PATCH", "DELETE"}, csrfMiddleware)
+//
+// Middleware added by Use and UseFor is applied in the order it was added.
+func (r *Router) UseFor(methods []string, mw ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.loadConfig().clone()
+	for _, m := range mw {
+		c.middleware = append(c.middleware, middlewareEntry{name: middlewareName(m), methods: methods, mw: m})
+	}
+
+	r.config.Store(c)
+}
+
+// UseNamed adds middleware like Use, but with the name reported by
+// MiddlewareChain instead of the name of the middleware function.
+func (r *Router) UseNamed(name string, mw Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.loadConfig().clone()
+	c.middleware = append(c.middleware, middlewareEntry{name: name, mw: mw})
+	r.config.Store(c)
+}
+
+// UseWhen adds middleware that wraps handlers only for requests with
+// normalized path for which the predicate returns true, for example:
+//
+//	UseWhen(func(path string) bool {
+//		return strings.HasPrefix(path, "/admin/")
+//	}, authMiddleware)
+//
+// The predicate is called for every request with the path the router
+// matches, which for mounted routers is the rest of the path. Middleware
+// added by Use, UseFor and UseWhen is applied in the order it was added,
+// regardless of the predicates, so the chain of a request is the middleware
+// that applies to it in that order.
+func (r *Router) UseWhen(predicate func(path string) bool, mw ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.loadConfig().clone()
+	for _, m := range mw {
+		c.middleware = append(c.middleware, middlewareEntry{name: middlewareName(m), when: predicate, mw: m})
+	}
+
+	r.config.Store(c)
+}
+
+// wrap wraps the handler with middleware that applies to the method and the
+// path.
+func (router *Router) wrap(h HandlerFunc, method string, path string) HandlerFunc {
+	middleware := router.loadConfig().middleware
+
+	// Wrap in reverse order, so that the first middleware runs first.
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if e := middleware[i]; e.appliesTo(method) && e.matches(path) {
+			h = e.mw(h)
+		}
+	}
+
+	return h
+}
+
+// wrap wraps the route handler with its own middleware that applies to the
+// method and the path.
+func (rt *route) wrap(method string, path string) HandlerFunc {
+	h := rt.handler
+	for i := len(rt.middleware) - 1; i >= 0; i-- {
+		if e := rt.middleware[i]; e.appliesTo(method) && e.matches(path) {
+			h = e.mw(h)
+		}
+	}
+
+	return h
+}
+
+// appliesTo reports whether middleware should be used for the method.
+func (e middlewareEntry) appliesTo(method string) bool {
+	if e.methods == nil {
+		return true
+	}
+
+	for _, m := range e.methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches reports whether middleware should be used for the path.
+func (e middlewareEntry) matches(path string) bool {
+	return e.when == nil || e.when(path)
+}
+
+// WithMiddleware wraps the route handler with middleware. Route middleware
+// runs after the middleware added to the router, in the order it is passed.
+func WithMiddleware(mw ...Middleware) RouteOption {
+	return func(rt *route) {
+		for _, m := range mw {
+			rt.middleware = append(rt.middleware, middlewareEntry{name: middlewareName(m), mw: m})
+		}
+	}
+}
+
+// MiddlewareChain returns names of the middleware that wraps the handler
+// registered for the method and pattern, in execution order: middleware
+// added to the router with Use, UseFor and UseNamed goes first, and the
+// route middleware goes last. Middleware added with UseWhen is included if
+// its predicate returns true for the normalized pattern. Middleware added
+// with UseNamed has the given name, other middleware is named after its
+// function, like "router.GzipWithMinSize". Returns nil if there is no such
+// route.
+//
+// It is a debugging aid and has no effect on request handling.
+func (r *Router) MiddlewareChain(method string, pattern string) []string {
+	// Parse pattern.
+	key, err := r.parsePattern(pattern)
+	if err != nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Try to get the route.
+	pd, ok := r.table.Load().routes[key.path]
+	if !ok || len(pd.methods[method]) == 0 {
+		return nil
+	}
+
+	names := []string{}
+	for _, e := range r.loadConfig().middleware {
+		if e.appliesTo(method) && e.matches(key.pattern()) {
+			names = append(names, e.name)
+		}
+	}
+
+	for _, e := range pd.methods[method][0].middleware {
+		if e.appliesTo(method) {
+			names = append(names, e.name)
+		}
+	}
+
+	return names
+}
+
+// Suffixes of names generated by compiler for closures and method values.
+var funcSuffix = regexp.MustCompile(`(\.func\d+|-fm)+$`)
+
+// middlewareName returns name of the function that created the middleware.
+func middlewareName(mw Middleware) string {
+	f := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+	if f == nil {
+		return ""
+	}
+
+	// Remove package path and closure suffixes.
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return funcSuffix.ReplaceAllString(name, "")
+}
@@ -0,0 +1,120 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// tag returns middleware that writes the label before calling the handler.
+func tag(label string) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, ps Params) {
+			w.Write([]byte(label + ","))
+			h(w, r, ps)
+		}
+	}
+}
+
+// csrf is a middleware function with a name.
+func csrf(h HandlerFunc) HandlerFunc {
+	return h
+}
+
+func TestUseFor(t *testing.T) {
+	rt := New()
+	rt.Use(tag("all"))
+	rt.UseFor([]string{"POST", "PUT", "DELETE"}, tag("csrf"))
+	rt.Get("/items", reply("get"))
+	rt.Post("/items", reply("post"))
+	rt.Put("/items", reply("put"))
+
+	tests := []struct {
+		method string
+		body   string
+	}{
+		{"GET", "all,get"},
+		{"POST", "all,csrf,post"},
+		{"PUT", "all,csrf,put"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, tt.method, "/items"); w.Body.String() != tt.body {
+			t.Errorf("%s: got %q, want %q", tt.method, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	rt := New()
+	rt.Use(Gzip())
+	rt.UseFor([]string{"POST"}, csrf)
+	rt.UseNamed("auth", tag("auth"))
+	rt.HandleWith("POST", "/items/:id", reply("ok"), WithMiddleware(tag("route")))
+	rt.Get("/items/:id", reply("ok"))
+
+	tests := []struct {
+		method string
+		chain  []string
+	}{
+		{"POST", []string{"router.GzipWithMinSize", "router.csrf", "auth", "router.tag"}},
+		{"GET", []string{"router.GzipWithMinSize", "auth"}},
+		{"PUT", nil},
+	}
+
+	for _, tt := range tests {
+		if got := rt.MiddlewareChain(tt.method, "/items/:name"); !reflect.DeepEqual(got, tt.chain) {
+			t.Errorf("%s: got %q, want %q", tt.method, got, tt.chain)
+		}
+	}
+
+	// Middleware runs in the reported order.
+	if w := serve(rt, "POST", "/items/1"); w.Body.String() != "auth,route,ok" {
+		t.Errorf("got %q, want %q", w.Body.String(), "auth,route,ok")
+	}
+}
+
+func TestUseWhen(t *testing.T) {
+	prefix := func(p string) func(string) bool {
+		return func(path string) bool {
+			return strings.HasPrefix(path, p)
+		}
+	}
+
+	rt, sub := New(), New()
+	rt.UseWhen(prefix("/api/"), tag("cors"))
+	rt.Use(tag("all"))
+	rt.UseWhen(prefix("/admin/"), tag("auth"))
+	rt.Get("/api/items", reply("ok"))
+	rt.Get("/admin/:page", reply("ok"))
+	rt.Get("/other", reply("ok"))
+	rt.Mount("/sub", sub)
+
+	// Mounted routers match the rest of the path.
+	sub.UseWhen(prefix("/admin/"), tag("sub auth"))
+	sub.Get("/admin/users", reply("ok"))
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/api/items", "cors,all,ok"},
+		{"/admin/users", "all,auth,ok"},
+		{"/other", "all,ok"},
+		{"/sub/admin/users", "all,sub auth,ok"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s: got %q, want %q", tt.path, w.Body.String(), tt.body)
+		}
+	}
+
+	// The chains of different patterns differ.
+	for pattern, want := range map[string]int{"/api/items": 2, "/admin/:page": 2, "/other": 1} {
+		if got := rt.MiddlewareChain("GET", pattern); len(got) != want {
+			t.Errorf("%s: got chain %q, want %d entries", pattern, got, want)
+		}
+	}
+}
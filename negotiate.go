@@ -0,0 +1,134 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrNoEncodings is returned by HandleEncodings if no handlers are passed.
+var ErrNoEncodings error = errors.New("router: at least one encoding handler is required")
+
+// An EncodedHandler is a handler that serves a response variant with the
+// content coding, like "br" or "gzip". The "identity" encoding means no
+// content coding.
+type EncodedHandler struct {
+	Encoding string
+	Handler  HandlerFunc
+}
+
+// acceptValue is an element of Accept-* header with its quality.
+type acceptValue struct {
+	value string
+	q     float64
+}
+
+// HandleEncodings sets handlers of precompressed response variants for
+// specific method and pattern. The handler is chosen by the Accept-Encoding
+// request header: the variant with the highest quality wins, and when
+// qualities are equal the one listed first wins. If no variant is accepted,
+// the "identity" handler is used, and if there is no such handler the router
+// responds with 406 Not Acceptable.
+//
+// The router sets "Vary: Accept-Encoding" header for all responses and
+// Content-Encoding header for all variants except identity.
+func (r *Router) HandleEncodings(method string, pattern string, handlers ...EncodedHandler) error {
+	if len(handlers) == 0 {
+		r.addError(method, pattern, ErrNoEncodings)
+		return ErrNoEncodings
+	}
+
+	return r.Handle(method, pattern, func(w http.ResponseWriter, req *http.Request, ps Params) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		// Choose variant.
+		eh := selectEncoding(handlers, req.Header.Get("Accept-Encoding"))
+		if eh == nil {
+			// Set status code to 406 Not Acceptable.
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+
+		if !isIdentity(eh.Encoding) {
+			w.Header().Set("Content-Encoding", eh.Encoding)
+		}
+
+		eh.Handler(w, req, ps)
+	})
+}
+
+// selectEncoding returns the most preferred of the accepted variants.
+func selectEncoding(handlers []EncodedHandler, header string) *EncodedHandler {
+	accepts := parseAccept(header)
+
+	var best, identity *EncodedHandler
+	bestQ := 0.0
+	for i := range handlers {
+		eh := &handlers[i]
+		if isIdentity(eh.Encoding) && identity == nil {
+			identity = eh
+		}
+
+		if q := encodingQuality(accepts, eh.Encoding); q > bestQ {
+			best, bestQ = eh, q
+		}
+	}
+
+	if best != nil {
+		return best
+	}
+
+	return identity
+}
+
+// encodingQuality returns quality of the encoding in the parsed
+// Accept-Encoding header.
+func encodingQuality(accepts []acceptValue, encoding string) float64 {
+	encoding = strings.ToLower(encoding)
+
+	// Wildcard applies if the encoding is not listed explicitly.
+	q := 0.0
+	for _, a := range accepts {
+		if a.value == encoding {
+			return a.q
+		}
+
+		if a.value == "*" {
+			q = a.q
+		}
+	}
+
+	return q
+}
+
+// isIdentity reports whether the encoding means no content coding.
+func isIdentity(encoding string) bool {
+	return encoding == "" || strings.EqualFold(encoding, "identity")
+}
+
+// parseAccept parses Accept-* header value into lowercase values with their
+// qualities. Values without quality have quality 1.
+func parseAccept(header string) []acceptValue {
+	var accepts []acceptValue
+	for _, v := range strings.Split(header, ",") {
+		parts := strings.Split(v, ";")
+		a := acceptValue{value: strings.ToLower(strings.TrimSpace(parts[0])), q: 1}
+		if a.value == "" {
+			continue
+		}
+
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					a.q = q
+				}
+			}
+		}
+
+		accepts = append(accepts, a)
+	}
+
+	return accepts
+}
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestHandleEncodings(t *testing.T) {
+	rt := New()
+	rt.HandleEncodings("GET", "/app.js",
+		EncodedHandler{"br", reply("br")},
+		EncodedHandler{"gzip", reply("gzip")},
+		EncodedHandler{"identity", reply("identity")})
+	rt.HandleEncodings("GET", "/app.css", EncodedHandler{"br", reply("br")})
+
+	tests := []struct {
+		accept   string
+		body     string
+		encoding string
+	}{
+		{"gzip, deflate, br", "br", "br"},
+		{"gzip", "gzip", "gzip"},
+		{"br;q=0.5, gzip;q=0.8", "gzip", "gzip"},
+		{"*", "br", "br"},
+		{"*;q=0.1, br;q=0", "gzip", "gzip"},
+
+		// Identity is used when no encoding matches.
+		{"", "identity", ""},
+		{"deflate", "identity", ""},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, "GET", "/app.js", "Accept-Encoding", tt.accept)
+		if w.Body.String() != tt.body || w.Header().Get("Content-Encoding") != tt.encoding {
+			t.Errorf("%q: got %q encoded with %q, want %q with %q", tt.accept, w.Body.String(),
+				w.Header().Get("Content-Encoding"), tt.body, tt.encoding)
+		}
+
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%q: got Vary %q, want Accept-Encoding", tt.accept, w.Header().Get("Vary"))
+		}
+	}
+
+	// Without identity handler, other encodings are not acceptable.
+	if w := serve(rt, "GET", "/app.css", "Accept-Encoding", "gzip"); w.Code != http.StatusNotAcceptable {
+		t.Errorf("got %d, want 406", w.Code)
+	}
+}
+
+func TestHandleEncodingsError(t *testing.T) {
+	rt := New()
+	if err := rt.HandleEncodings("GET", "/none"); err != ErrNoEncodings {
+		t.Errorf("got %v, want %v", err, ErrNoEncodings)
+	}
+
+	// Build reports the error too.
+	if err := rt.Build(); !errors.Is(err, ErrNoEncodings) {
+		t.Errorf("got %v from Build, want %v", err, ErrNoEncodings)
+	}
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// orderRoute is a route registered in random order by TestRegistrationOrder.
+type orderRoute struct {
+	pattern string
+	options []RouteOption
+}
+
+var orderRoutes = []orderRoute{
+	{"/", nil},
+	{"/users", nil},
+	{"/users/me", nil},
+	{"/users/:id", nil},
+	{`/users/:id(\d+)`, nil},
+	{"/users/:id/posts", nil},
+	{"/users/:id/posts/:post", nil},
+	{"/users/:id.json", nil},
+	{"/files/*path", nil},
+	{"/files/:name", nil},
+	{"/files/readme", nil},
+	{"/a/:b/c", nil},
+	{"/a/b/:c", nil},
+	{"/priority/*path", []RouteOption{WithPriority(1)}},
+	{"/priority/:name", nil},
+	{"/*rest", nil},
+}
+
+var orderPaths = []string{
+	"/", "/users", "/users/me", "/users/5", "/users/bob", "/users/5/posts", "/users/5/posts/7",
+	"/users/5.json", "/files/readme", "/files/a.txt", "/files/a/b", "/a/b/c", "/a/x/c", "/a/b/x",
+	"/priority/x", "/other/path",
+}
+
+func TestRegistrationOrder(t *testing.T) {
+	// build registers the routes in the order, with handlers that write their
+	// patterns.
+	build := func(order []int) *Router {
+		rt := New()
+		for _, i := range order {
+			route := orderRoutes[i]
+			if err := rt.HandleWith("GET", route.pattern, reply(route.pattern), route.options...); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return rt
+	}
+
+	order := make([]int, len(orderRoutes))
+	for i := range order {
+		order[i] = i
+	}
+
+	want := map[string]string{}
+	rt := build(order)
+	for _, path := range orderPaths {
+		want[path] = serve(rt, "GET", path).Body.String()
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 50; n++ {
+		rnd.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+		rt := build(order)
+		for _, path := range orderPaths {
+			if got := serve(rt, "GET", path).Body.String(); got != want[path] {
+				t.Fatalf("order %v: %s matched %q, want %q", order, path, got, want[path])
+			}
+		}
+	}
+}
+
+func TestHostOrder(t *testing.T) {
+	hosts := []string{":tenant.app.com", ":id.app.com", "www.app.com"}
+
+	var want []string
+	for n := 0; n < 10; n++ {
+		rnd := rand.New(rand.NewSource(int64(n)))
+		rnd.Shuffle(len(hosts), func(i, j int) { hosts[i], hosts[j] = hosts[j], hosts[i] })
+
+		rt := New()
+		for _, h := range hosts {
+			rt.Host(h).Get("/", reply(h))
+		}
+
+		got := []string{serveHost(rt, "acme.app.com", "/"), serveHost(rt, "www.app.com", "/")}
+		if want == nil {
+			want = got
+			if want[1] != "www.app.com" {
+				t.Errorf("got %q for host without parameters", want[1])
+			}
+		}
+
+		if got[0] != want[0] || got[1] != want[1] {
+			t.Fatalf("hosts %v: got %q, want %q", hosts, got, want)
+		}
+	}
+}
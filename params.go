@@ -0,0 +1,210 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// NewParams returns parameters built from key/value pairs, for example:
+//
+//	ps := NewParams("id", "42", "tag", "a", "tag", "b")
+//
+// Repeated keys add values in order. It is useful for calling handlers
+// directly in tests. NewParams panics if the number of arguments is odd.
+func NewParams(pairs ...string) Params {
+	if len(pairs)%2 != 0 {
+		panic("router: NewParams requires even number of arguments")
+	}
+
+	ps := Params{}
+	for i := 0; i < len(pairs); i += 2 {
+		ps[pairs[i]] = append(ps[pairs[i]], pairs[i+1])
+	}
+
+	return ps
+}
+
+// GetAll returns all values for parameter with specified name.
+func (ps Params) GetAll(name string) []string {
+	return ps[name]
+}
+
+// A ParamError describes a parameter that is missing or has a value that
+// cannot be converted to the requested type.
+type ParamError struct {
+	Name   string
+	Value  string
+	Reason string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("router: parameter %q value %q: %s", e.Name, e.Value, e.Reason)
+}
+
+// GetInt returns value for parameter with specified name converted to int.
+// If parameter has several values, first one is used. Returns false if the
+// parameter is missing or is not an integer.
+func (ps Params) GetInt(name string) (int, bool) {
+	n, err := ps.TryGetInt(name)
+	return n, err == nil
+}
+
+// TryGetInt is like GetInt, but returns a *ParamError that explains why the
+// value cannot be returned.
+func (ps Params) TryGetInt(name string) (int, error) {
+	v, ok := ps.Get(name)
+	if !ok {
+		return 0, &ParamError{Name: name, Reason: "missing"}
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		reason := "not an integer"
+		if errors.Is(err, strconv.ErrRange) {
+			reason = "integer out of range"
+		}
+
+		return 0, &ParamError{Name: name, Value: v, Reason: reason}
+	}
+
+	return n, nil
+}
+
+// GetTime returns value for parameter with specified name parsed with the
+// layout, like time.Parse does, for example:
+//
+//	date, ok := ps.GetTime("date", "2006-01-02")
+//
+// If parameter has several values, first one is used. Returns false if the
+// parameter is missing or cannot be parsed.
+func (ps Params) GetTime(name string, layout string) (time.Time, bool) {
+	v, ok := ps.Get(name)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(layout, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// Vars returns the first value of every parameter of the request, like
+// Vars of gorilla/mux. It requires Router.ParamsInContext to be set and
+// returns nil otherwise. Changes to the map do not affect Params.
+func Vars(r *http.Request) map[string]string {
+	ps, ok := ParamsFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+
+	vars := make(map[string]string, len(ps))
+	for k, v := range ps {
+		if len(v) > 0 {
+			vars[k] = v[0]
+		}
+	}
+
+	return vars
+}
+
+// ExtractParams returns parameters captured from the path by the pattern
+// and whether the pattern matches the path, as the router with default
+// settings would match it, without registering a route. The pattern uses
+// the default syntax with parameters starting with ":". It reports false
+// if the pattern is invalid.
+func ExtractParams(pattern string, path string) (Params, bool) {
+	pd, err := parsePatternWith(pattern, ":", "")
+	if err != nil {
+		return nil, false
+	}
+
+	values, _, ok := pd.match(splitPath(normalizePath(path)))
+	if !ok {
+		return nil, false
+	}
+
+	return pd.uriParams(values), true
+}
+
+// Ordered returns the parameters of the request as name and value pairs:
+// first the parameters captured from the path, in the order they are
+// declared by the pattern of the matched route, including mount patterns,
+// then the other values, like form values, by names in lexical order, with
+// several values of the same name in their order in Params. It lets
+// handlers rebuild URLs or log parameters in a stable order. Params is a
+// map that does not keep the order of declaration, so it is taken from the
+// route that matched the request.
+func (ps Params) Ordered(r *http.Request) [][2]string {
+	var names []string
+	var levels []int
+	if ri, ok := r.Context().Value(routeContextKey).(*routeInfo); ok {
+		names, levels = ri.params, ri.levels
+	}
+
+	// Path values of the same name go level by level in Params, from the
+	// pattern of the route to the outermost mount pattern, because values
+	// captured by the mount patterns follow own values. Within a pattern
+	// they go in the order of declaration.
+	index := make([]int, len(names))
+	declared := map[string]int{}
+	end := len(names)
+	for l := len(levels) - 1; l >= 0; l-- {
+		start := end - levels[l]
+		for i := start; i < end; i++ {
+			index[i] = declared[names[i]]
+			declared[names[i]]++
+		}
+
+		end = start
+	}
+
+	pairs := make([][2]string, 0, len(ps))
+	for i, name := range names {
+		if v := ps[name]; index[i] < len(v) {
+			pairs = append(pairs, [2]string{name, v[index[i]]})
+		}
+	}
+
+	keys := make([]string, 0, len(ps))
+	for k := range ps {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := ps[k]
+		if n := declared[k]; n < len(v) {
+			for _, s := range v[n:] {
+				pairs = append(pairs, [2]string{k, s})
+			}
+		}
+	}
+
+	return pairs
+}
+
+// Clone returns a copy of the parameters that shares no memory with them.
+// Params passed to a handler are not used by the router after the handler
+// returns, so cloning is only needed if the parameters are modified while
+// they are read concurrently, for example by goroutines started by the
+// handler, or if they are kept and the original may still be modified.
+func (ps Params) Clone() Params {
+	if ps == nil {
+		return nil
+	}
+
+	c := make(Params, len(ps))
+	for k, v := range ps {
+		c[k] = append([]string(nil), v...)
+	}
+
+	return c
+}
@@ -0,0 +1,167 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewParams(t *testing.T) {
+	ps := NewParams("id", "42")
+	if v, ok := ps.Get("id"); !ok || v != "42" {
+		t.Errorf("got %q %v, want %q", v, ok, "42")
+	}
+
+	ps = NewParams("tag", "a", "id", "1", "tag", "b")
+	if got, want := ps.GetAll("tag"), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for odd number of arguments")
+		}
+	}()
+
+	NewParams("id", "1", "tag")
+}
+
+func TestParamsClone(t *testing.T) {
+	ps := NewParams("tag", "a", "tag", "b")
+	c := ps.Clone()
+
+	c["tag"][0] = "changed"
+	c["tag"] = append(c["tag"], "c")
+	c["new"] = []string{"x"}
+
+	want := NewParams("tag", "a", "tag", "b")
+	if !reflect.DeepEqual(ps, want) {
+		t.Errorf("original changed to %v, want %v", ps, want)
+	}
+}
+
+func TestTryGetInt(t *testing.T) {
+	ps := NewParams("id", "7", "name", "x1", "big", "99999999999999999999")
+
+	if n, err := ps.TryGetInt("id"); n != 7 || err != nil {
+		t.Errorf("got %d %v, want 7", n, err)
+	}
+
+	tests := []struct {
+		name string
+		err  ParamError
+	}{
+		{"missing", ParamError{Name: "missing", Reason: "missing"}},
+		{"name", ParamError{Name: "name", Value: "x1", Reason: "not an integer"}},
+		{"big", ParamError{Name: "big", Value: "99999999999999999999", Reason: "integer out of range"}},
+	}
+
+	for _, tt := range tests {
+		_, err := ps.TryGetInt(tt.name)
+
+		var pe *ParamError
+		if !errors.As(err, &pe) || *pe != tt.err {
+			t.Errorf("%s: got %v, want %v", tt.name, err, &tt.err)
+		}
+	}
+}
+
+func TestGetTime(t *testing.T) {
+	ps := NewParams("date", "2024-01-02", "date", "bad", "us", "01/02/2024")
+
+	want := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	if d, ok := ps.GetTime("date", "2006-01-02"); !ok || !d.Equal(want) {
+		t.Errorf("got %v %v, want %v", d, ok, want)
+	}
+
+	for _, name := range []string{"us", "missing"} {
+		if d, ok := ps.GetTime(name, "2006-01-02"); ok {
+			t.Errorf("%s: got %v, want false", name, d)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	var got map[string]string
+
+	rt := New()
+	rt.Get("/users/:id", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		got = Vars(r)
+	})
+
+	// Without ParamsInContext there are no params in the context.
+	serve(rt, "GET", "/users/7")
+	if got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+
+	rt.ParamsInContext = true
+	serve(rt, "GET", "/users/7?tag=a&tag=b")
+
+	want := map[string]string{"id": "7", "tag": "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	ordered := func(w http.ResponseWriter, r *http.Request, ps Params) {
+		fmt.Fprint(w, ps.Ordered(r))
+	}
+
+	rt, sub, inner := New(), New(), New()
+	rt.Get("/a/:x/b/:x", ordered)
+	rt.Mount("/t/:tenant/:id", sub)
+	sub.Get("/:z/:a/:id", ordered)
+	sub.Mount("/m/:id", inner)
+	inner.Get("/:id/:id", ordered)
+
+	tests := []struct {
+		path  string
+		pairs string
+	}{
+		{"/a/1/b/2", "[[x 1] [x 2]]"},
+		{"/a/1/b/2?x=3&c=4", "[[x 1] [x 2] [c 4] [x 3]]"},
+		{"/t/acme/1/zz/aa/2?b=x&a=q&b=y", "[[tenant acme] [id 1] [z zz] [a aa] [id 2] [a q] [b x] [b y]]"},
+		{"/t/acme/1/m/2/3/4", "[[tenant acme] [id 1] [id 2] [id 3] [id 4]]"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.pairs {
+			t.Errorf("%s: got %s, want %s", tt.path, w.Body.String(), tt.pairs)
+		}
+	}
+
+	// Requests not matched by a router have no path parameters.
+	req, _ := http.NewRequest("GET", "/", nil)
+	if got := fmt.Sprint(NewParams("b", "2", "a", "1").Ordered(req)); got != "[[a 1] [b 2]]" {
+		t.Errorf("got %s, want [[a 1] [b 2]]", got)
+	}
+}
+
+func TestExtractParams(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		params  Params
+		ok      bool
+	}{
+		{"/a/b", "/a/b", Params{}, true},
+		{"/users/:id", "/users/42", NewParams("id", "42"), true},
+		{"/files/*rest", "/files/x/y", NewParams("rest", "x/y"), true},
+		{"/users/:id", "/users", nil, false},
+		{"/users/:id", "/users/1/2", nil, false},
+		{"/a/b", "/a/c", nil, false},
+		{"/users/::id", "/users/1", nil, false},
+	}
+
+	for _, tt := range tests {
+		ps, ok := ExtractParams(tt.pattern, tt.path)
+		if ok != tt.ok || ok && !reflect.DeepEqual(ps, tt.params) {
+			t.Errorf("%s on %s: got %v %v, want %v %v", tt.pattern, tt.path, ps, ok, tt.params, tt.ok)
+		}
+	}
+}
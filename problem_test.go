@@ -0,0 +1,42 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestProblemJSON(t *testing.T) {
+	rt := New()
+	rt.ProblemJSON = true
+	rt.Get("/a", reply(""))
+	rt.Post("/a", reply(""))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/missing", http.StatusNotFound,
+			`{"type":"about:blank","title":"Not Found","status":404}`},
+		{"PUT", "/a", http.StatusMethodNotAllowed,
+			`{"type":"about:blank","title":"Method Not Allowed","status":405,"detail":"Allowed methods: GET, POST"}`},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, tt.method, tt.path)
+		if w.Code != tt.status || w.Body.String() != tt.body+"\n" {
+			t.Errorf("%s %s: got %d %q, want %d %q", tt.method, tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+
+		if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("%s %s: got Content-Type %q, want application/problem+json", tt.method, tt.path, ct)
+		}
+	}
+
+	// NotFoundBody wins.
+	rt.NotFoundBody = []byte("not found")
+	if w := serve(rt, "GET", "/missing"); w.Body.String() != "not found" {
+		t.Errorf("got %q, want %q", w.Body.String(), "not found")
+	}
+}
@@ -0,0 +1,30 @@
+package router
+
+// A MatchProfile reports how the router matched a path. It is returned by
+// Router.Profile.
+type MatchProfile struct {
+	// Pattern is the normalized pattern that matched the path, or empty
+	// string if no pattern matched. For mounted routers it is the mount
+	// pattern.
+	Pattern string
+
+	// Comparisons is the number of comparisons the matcher made: one for
+	// the lookup of static paths, and one for every pattern with named
+	// parameters or mounted router the path was compared with.
+	Comparisons int
+}
+
+// Profile matches the path like the router matches request paths and
+// reports the number of comparisons it took, to help tune the route table,
+// for example by raising the priority of frequently requested routes or by
+// replacing parameters with static segments. Methods and other request
+// properties are not taken into account, and paths handled by host routers
+// are matched by the routes of this router only.
+func (r *Router) Profile(path string) MatchProfile {
+	var p MatchProfile
+	if pd, _, _ := r.table.Load().getPathData(path, nil, &p.Comparisons); pd != nil {
+		p.Pattern = pd.pattern()
+	}
+
+	return p
+}
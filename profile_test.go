@@ -0,0 +1,57 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	rt := New()
+	rt.Get("/a", reply("a"))
+	rt.Get("/users/:id", reply("user"))
+	rt.Get("/users/:id/posts", reply("posts"))
+	rt.Get("/*all", reply("all"))
+
+	tests := []struct {
+		path        string
+		pattern     string
+		comparisons int
+	}{
+		{"/a", "/a", 1},
+		{"/users/7", "/users/:id", 3},
+		{"/zzz", "/*all", 4},
+	}
+
+	for _, tt := range tests {
+		if p := rt.Profile(tt.path); p.Pattern != tt.pattern || p.Comparisons != tt.comparisons {
+			t.Errorf("%s: got %q after %d comparisons, want %q after %d",
+				tt.path, p.Pattern, p.Comparisons, tt.pattern, tt.comparisons)
+		}
+	}
+}
+
+// benchmarkLookup measures serving the path by a router with the routes of
+// a typical API.
+func benchmarkLookup(b *testing.B, path string) {
+	rt := New()
+	rt.Get("/", reply(""))
+	rt.Get("/users", reply(""))
+	rt.Get("/users/:id", reply(""))
+	rt.Get("/users/:id/posts/:post", reply(""))
+	rt.Get("/static/*path", reply(""))
+
+	req := httptest.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rt.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkStatic(b *testing.B)   { benchmarkLookup(b, "/users") }
+func BenchmarkParam(b *testing.B)    { benchmarkLookup(b, "/users/7") }
+func BenchmarkParams(b *testing.B)   { benchmarkLookup(b, "/users/7/posts/9") }
+func BenchmarkCatchAll(b *testing.B) { benchmarkLookup(b, "/static/css/site.css") }
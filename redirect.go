@@ -0,0 +1,30 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrRedirectCode is returned by Router.Redirect if the status code is not
+// a redirection (3xx) code.
+var ErrRedirectCode error = errors.New("router: redirect status code must be 3xx")
+
+// Redirect adds handler that redirects requests with the method and path
+// matching the pattern to the target URL, for example:
+//
+//	err := Redirect("GET", "/old", "/new", http.StatusMovedPermanently)
+//
+// The target is used as is: parameters of the pattern are not substituted.
+// A relative target is resolved against the request path, like
+// http.Redirect does.
+func (r *Router) Redirect(method string, pattern string, to string, code int) error {
+	// Check status code.
+	if code < 300 || code > 399 {
+		r.addError(method, pattern, ErrRedirectCode)
+		return ErrRedirectCode
+	}
+
+	return r.Handle(method, pattern, func(w http.ResponseWriter, req *http.Request, _ Params) {
+		http.Redirect(w, req, to, code)
+	})
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	rt := New()
+	for _, code := range []int{200, 299, 400} {
+		if err := rt.Redirect("GET", "/old", "/new", code); err != ErrRedirectCode {
+			t.Errorf("%d: got %v, want %v", code, err, ErrRedirectCode)
+		}
+	}
+
+	if err := rt.Build(); !errors.Is(err, ErrRedirectCode) {
+		t.Errorf("got %v from Build, want %v", err, ErrRedirectCode)
+	}
+
+	if err := rt.Redirect("GET", "/old", "/new", http.StatusMovedPermanently); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rt.Redirect("POST", "/old", "https://example.com/new", http.StatusPermanentRedirect); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method   string
+		status   int
+		location string
+	}{
+		{"GET", http.StatusMovedPermanently, "/new"},
+		{"POST", http.StatusPermanentRedirect, "https://example.com/new"},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, tt.method, "/old")
+		if w.Code != tt.status || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s: got %d to %q, want %d to %q", tt.method, w.Code, w.Header().Get("Location"), tt.status, tt.location)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Route describes a route registered with Router.Register.
+type Route struct {
+	Method  string
+	Pattern string
+	Handler HandlerFunc
+	Options []RouteOption
+}
+
+// A RouteError records a failed route registration.
+type RouteError struct {
+	Method  string
+	Pattern string
+	Err     error
+}
+
+func (e *RouteError) Error() string {
+	return e.Method + " " + e.Pattern + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// RegistrationErrors is returned by Router.Register and contains errors of
+// all routes that failed to register. errors.Is reports whether any of them
+// matches the target.
+type RegistrationErrors []*RouteError
+
+func (e RegistrationErrors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("router: %d route(s) failed to register:", len(e)))
+	for _, err := range e {
+		lines = append(lines, "\t"+err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns errors of the failed routes.
+func (e RegistrationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// Register adds all routes from the table. Unlike calling Handle for every
+// route, registration does not stop on the first error: all routes are
+// tried, and errors of the failed ones are returned as RegistrationErrors.
+// Returns nil if all routes were registered.
+func (r *Router) Register(routes []Route) error {
+	var errs RegistrationErrors
+	for _, rt := range routes {
+		if err := r.HandleWith(rt.Method, rt.Pattern, rt.Handler, rt.Options...); err != nil {
+			errs = append(errs, &RouteError{Method: rt.Method, Pattern: rt.Pattern, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// MustHandle is like Handle but panics if the route cannot be registered.
+// It simplifies registration of static route tables during initialization.
+// The panic value is a *RouteError with the method and the pattern.
+func (r *Router) MustHandle(method string, pattern string, handler HandlerFunc) {
+	if err := r.Handle(method, pattern, handler); err != nil {
+		panic(&RouteError{Method: method, Pattern: pattern, Err: err})
+	}
+}
+
+// MustGet is like Get but panics if the route cannot be registered.
+func (r *Router) MustGet(pattern string, handler HandlerFunc) {
+	r.MustHandle("GET", pattern, handler)
+}
+
+// MustPut is like Put but panics if the route cannot be registered.
+func (r *Router) MustPut(pattern string, handler HandlerFunc) {
+	r.MustHandle("PUT", pattern, handler)
+}
+
+// MustPost is like Post but panics if the route cannot be registered.
+func (r *Router) MustPost(pattern string, handler HandlerFunc) {
+	r.MustHandle("POST", pattern, handler)
+}
+
+// MustDelete is like Delete but panics if the route cannot be registered.
+func (r *Router) MustDelete(pattern string, handler HandlerFunc) {
+	r.MustHandle("DELETE", pattern, handler)
+}
+
+// Merge adds all routes of the child router to the router, including
+// routers mounted to the child. Like Register, it does not stop on the first
+// error: routes that conflict with the routes of the router are skipped, and
+// their errors are returned as RegistrationErrors.
+//
+// Routes keep their options. Middleware added to the child router is copied
+// to its routes, so it runs after the middleware of the router and before
+// the route middleware, but not for the routers mounted to the child. Other
+// settings of the child router, such as PanicHandler or NotFound, are not
+// used. Routes and middleware added to the child router after merging do
+// not affect the router.
+func (r *Router) Merge(child *Router) error {
+	var errs RegistrationErrors
+	for _, e := range child.mergeEntries() {
+		pattern := e.pd.pattern()
+
+		// Mount the routers mounted to the child. Path data is copied,
+		// so that it is not shared with the child, and the pattern is not
+		// parsed again, since the router may use other parameter markers.
+		if e.pd.mount != nil {
+			pd := &pathData{path: e.pd.path, params: e.pd.params, segments: e.pd.segments, methods: pathMethods{}, mount: e.pd.mount, template: e.pd.template}
+			if err := r.addMount(pd); err != nil {
+				errs = append(errs, &RouteError{Pattern: pattern, Err: err})
+			}
+
+			continue
+		}
+
+		// Path data is copied, so that it is not shared with the child.
+		pd := &pathData{path: e.pd.path, params: e.pd.params, segments: e.pd.segments, methods: pathMethods{}}
+		if err := r.addRoute(e.method, pd, e.route); err != nil {
+			errs = append(errs, &RouteError{Method: e.method, Pattern: pattern, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// mergeEntry is a route or a mounted router added by Router.Merge.
+type mergeEntry struct {
+	method string
+	pd     *pathData
+	route  *route
+}
+
+// mergeEntries returns copies of the routes with the router middleware
+// prepended to their own, and the mounted routers, in the order of Walk.
+func (r *Router) mergeEntries() []mergeEntry {
+	middleware := r.loadConfig().middleware
+
+	var entries []mergeEntry
+	for _, pd := range r.table.Load().sorted() {
+		if pd.mount != nil {
+			entries = append(entries, mergeEntry{pd: pd})
+			continue
+		}
+
+		for _, m := range pd.allowedMethods() {
+			for _, rt := range pd.methods[m] {
+				c := *rt
+				c.middleware = append(append([]middlewareEntry(nil), middleware...), rt.middleware...)
+				entries = append(entries, mergeEntry{method: m, pd: pd, route: &c})
+			}
+		}
+	}
+
+	return entries
+}
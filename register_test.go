@@ -0,0 +1,123 @@
+package router
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	rt := New()
+	err := rt.Register([]Route{
+		{Method: "GET", Pattern: "/items", Handler: reply("list")},
+		{Method: "GET", Pattern: "/items", Handler: reply("again")},
+		{Method: "GET", Pattern: "/items/::id", Handler: reply("item")},
+		{Method: "POST", Pattern: "/items", Handler: reply("create")},
+	})
+
+	var errs RegistrationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("got %v, want 2 registration errors", err)
+	}
+
+	if !errors.Is(err, ErrDuplicateHandler) || !errors.Is(err, ErrParameterName) {
+		t.Errorf("got %v, want both %v and %v", err, ErrDuplicateHandler, ErrParameterName)
+	}
+
+	want := strings.Join([]string{
+		"router: 2 route(s) failed to register:",
+		"\tGET /items: " + ErrDuplicateHandler.Error(),
+		"\tGET /items/::id: " + ErrParameterName.Error(),
+	}, "\n")
+	if err.Error() != want {
+		t.Errorf("got message\n%s\nwant\n%s", err, want)
+	}
+
+	// Routes without errors are registered.
+	if w := serve(rt, "POST", "/items"); w.Body.String() != "create" {
+		t.Errorf("got %q, want %q", w.Body.String(), "create")
+	}
+
+	if err := rt.Register([]Route{{Method: "PUT", Pattern: "/items", Handler: reply("put")}}); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func TestMustHandle(t *testing.T) {
+	rt := New()
+	rt.MustGet("/items", reply("list"))
+	rt.MustPost("/items", reply("create"))
+
+	if w := serve(rt, "POST", "/items"); w.Body.String() != "create" {
+		t.Errorf("got %q, want %q", w.Body.String(), "create")
+	}
+
+	defer func() {
+		err, ok := recover().(*RouteError)
+		if !ok {
+			t.Fatalf("got panic %v, want *RouteError", err)
+		}
+
+		if err.Method != "GET" || err.Pattern != "/items" || !errors.Is(err, ErrDuplicateHandler) {
+			t.Errorf("got %v", err)
+		}
+	}()
+
+	rt.MustGet("/items", reply("again"))
+}
+
+func TestMerge(t *testing.T) {
+	parent, child, sub := New(), New(), New()
+	parent.Use(tag("parent"))
+	parent.Get("/a", reply("parent a"))
+
+	child.Use(tag("child"))
+	child.UseFor([]string{"POST"}, tag("post"))
+	child.Get("/a", reply("child a"))
+	child.HandleWith("GET", "/b/:id", reply("child b"), WithMiddleware(tag("route")))
+	child.Post("/b/:id", reply("child post b"))
+	sub.Get("/x", reply("sub x"))
+	child.Mount("/m", sub)
+
+	// The conflicting route is skipped.
+	err := parent.Merge(child)
+
+	var errs RegistrationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Pattern != "/a" || !errors.Is(err, ErrDuplicateHandler) {
+		t.Fatalf("got %v, want duplicate handler error for /a", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/a", "parent,parent a"},
+		{"GET", "/b/1", "parent,child,route,child b"},
+		{"POST", "/b/1", "parent,child,post,child post b"},
+
+		// Middleware of the child is not used for the routers mounted to it.
+		{"GET", "/m/x", "parent,sub x"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(parent, tt.method, tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s %s: got %q, want %q", tt.method, tt.path, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestMergeParamPrefix(t *testing.T) {
+	parent, child, inner := New(), New(), New()
+	parent.ParamPrefix = "{"
+	inner.Get("/x", echo)
+	child.Mount("/t/:tenant", inner)
+
+	if err := parent.Merge(child); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := serve(parent, "GET", "/t/acme/x"); w.Body.String() != "/x map[tenant:[acme]]" {
+		t.Errorf("got %d %q, want the tenant parameter", w.Code, w.Body.String())
+	}
+}
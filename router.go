@@ -3,20 +3,72 @@
 package router
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 const (
 	wrongParamNameChars string = `/:`
 )
 
+// contextKey is a key for values stored by router in the request context.
+type contextKey struct {
+	name string
+}
+
+func (k *contextKey) String() string {
+	return "router context value " + k.name
+}
+
+// ParamsContextKey is a context key. Routers with ParamsInContext set store
+// Params of the matched route under it, so that code without access to the
+// handler arguments can read them. The associated value is of type Params.
+// The key is stable and is not changed by new versions of the package.
+var ParamsContextKey = &contextKey{"vars"}
+
+var (
+	// paramsContextKey stores parameters captured from the URI by the outer
+	// router for requests passed to a mounted router.
+	paramsContextKey = &contextKey{"params"}
+
+	// routeContextKey stores information about the matched route.
+	routeContextKey = &contextKey{"route"}
+)
+
+// routeInfo describes the route that matched the request.
+type routeInfo struct {
+	pattern  string
+	params   []string
+	template string
+
+	// levels is the number of params declared by every pattern, from the
+	// outermost mount pattern to the pattern of the route.
+	levels []int
+
+	// router is the router that matched the route.
+	router *Router
+}
+
 // Router errors.
 var (
 	ErrParameterName    error = errors.New(fmt.Sprintf("router: parameter name cannot contain any of these charactars: %#q", wrongParamNameChars))
 	ErrDuplicateHandler error = errors.New("router: handler for this path and method combination was already registered")
+	ErrCatchAll         error = errors.New("router: catch-all parameter must be the last segment of the pattern")
+	ErrParameterPattern error = errors.New("router: invalid parameter regular expression")
+	ErrMethod           error = errors.New("router: unknown HTTP method")
 )
 
 // A HandlerFunc represents an HTTP request handler function.
@@ -26,26 +78,359 @@ type HandlerFunc func(w http.ResponseWriter, r *http.Request, ps Params)
 // called in case of panic during the request handlind.
 type PanicHandlerFunc func(w http.ResponseWriter, r *http.Request, err interface{})
 
+// A Middleware wraps a handler function to perform some steps before
+// and/or after the request handling.
+type Middleware func(HandlerFunc) HandlerFunc
+
+// A WalkFunc is the type of the function called by Router.Walk for every
+// registered route. If it returns an error, walking stops and the error is
+// returned by Walk.
+type WalkFunc func(method string, pattern string, handler HandlerFunc) error
+
 // A Params stores parameters that were passed as a part of URI.
 type Params map[string][]string
 
 // A Router stores all routes with corresponding API handler functions.
+// Routes may be added and removed while the router is serving requests.
 type Router struct {
-	routes       map[string]*pathData
+	mu           sync.RWMutex
+	table        atomic.Pointer[routeTable]
+	config       atomic.Pointer[routerConfig]
+	errs         RegistrationErrors
+	trackUse     atomic.Bool
+	sweeper      chan struct{}
 	PanicHandler PanicHandlerFunc
+
+	// PanicLogger is called instead of PanicHandler for panics that happen
+	// after the request context was cancelled, for example because the
+	// client went away. No response is written for such requests, so the
+	// function should only record the panic. Deadlines set by Timeouts do
+	// not count, so handlers that panic after them are handled as usual.
+	PanicLogger func(r *http.Request, err interface{})
+
+	// PreRoute is called for every request before route matching. If it
+	// returns false, request handling stops: the hook is expected to have
+	// written the response. Panics in the hook are handled like panics in
+	// request handlers.
+	PreRoute func(w http.ResponseWriter, r *http.Request) bool
+
+	// NotFound is called when no route matches the request path. If it is
+	// nil, NotFoundBody is written with 404 Not Found status code.
+	NotFound HandlerFunc
+
+	// MissingResource is called by ResourceNotFound, that handlers of the
+	// router call when the resource identified by a matched path does not
+	// exist, for example a user with the requested id. It lets responses
+	// about missing resources differ from responses about unknown paths,
+	// written by NotFound. If it is nil, ResourceNotFound responds with
+	// 404 Not Found without body.
+	MissingResource HandlerFunc
+
+	// NotFoundBody is written as response body for unmatched requests if
+	// NotFound handler is not set. NotFoundContentType is used as its
+	// Content-Type header value if not empty.
+	NotFoundBody        []byte
+	NotFoundContentType string
+
+	// ProblemJSON makes the router write RFC 7807 problem documents with
+	// "application/problem+json" content type as the bodies of 404 Not
+	// Found and 405 Method Not Allowed responses, unless NotFound handler
+	// or NotFoundBody is set. The 405 document lists the allowed methods in
+	// its detail.
+	ProblemJSON bool
+
+	// DisableTrace makes the router respond with 405 Method Not Allowed to
+	// all TRACE requests right after PreRoute, even if routes are registered
+	// for TRACE, to prevent cross-site tracing. The Allow header lists the
+	// other methods of the routes of the path. With Hide405 set, TRACE
+	// requests are handled like requests for paths without routes instead.
+	DisableTrace bool
+
+	// Maintenance makes the router respond with 503 Service Unavailable to
+	// all requests, except for MaintenancePaths, without routing them, for
+	// planned downtime. It can be switched while the router is serving
+	// requests, for example:
+	//
+	//	r.Maintenance.Store(true)
+	Maintenance atomic.Bool
+
+	// MaintenanceHandler is called instead of the 503 response during
+	// maintenance if it is not nil, after the Retry-After header is set.
+	MaintenanceHandler HandlerFunc
+
+	// RetryAfter sets the Retry-After header of the responses during
+	// maintenance, rounded up to seconds, if positive.
+	RetryAfter time.Duration
+
+	// MaintenancePaths lists paths, like "/health", that are routed as
+	// usual during maintenance. Paths are compared like static patterns.
+	MaintenancePaths []string
+
+	// Hide405 makes the router respond with 404 Not Found, without the
+	// Allow header, instead of 405 Method Not Allowed, so that responses do
+	// not reveal whether a path has routes for other methods. Such requests
+	// are handled exactly like requests for paths without routes.
+	Hide405 bool
+
+	// RedirectCanonical makes the router redirect GET and HEAD requests
+	// with backslashes or duplicate slashes in the path to the path with
+	// these fixed, with 301 Moved Permanently, instead of serving them.
+	// Only requests that match a route are redirected.
+	RedirectCanonical bool
+
+	// ParamPrefix sets the marker of named parameters in patterns, that is
+	// ":" by default. "{" makes parameters enclosed in braces, so that
+	// "/users/{id}" is the same as "/users/:id", and any other value is
+	// used as a prefix. Segments that start with ":" are static with other
+	// markers. It must be set before routes are registered.
+	ParamPrefix string
+
+	// RedirectLowercase makes the router redirect GET and HEAD requests
+	// with upper case letters in the path to the lower case path, with 301
+	// Moved Permanently, instead of serving them. If PreserveParamCase is
+	// set, only static segments of the matched pattern are lowercased.
+	// Only requests that match a route are redirected.
+	RedirectLowercase bool
+
+	// PreserveParamCase makes parameter values captured from the path, and
+	// the path passed to mounted routers, keep their original case. Paths
+	// are still matched in lower case. By default values are in lower case.
+	PreserveParamCase bool
+
+	// EncodedSlashes makes the values of catch-all parameters keep encoded
+	// slashes, so that "/files/a%2Fb" captures "a%2Fb" for "/files/*path",
+	// while "/files/a/b" captures "a/b". Other escapes are decoded as usual.
+	// Paths are still matched after decoding, so "/files/a%2Fb" matches
+	// the same routes as "/files/a/b", and the router cannot tell encoded
+	// slashes from plain ones before the value of the catch-all parameter.
+	// The value is decoded if the client sent an unnecessary escape that Go
+	// does not preserve, in which case encoded slashes are lost.
+	EncodedSlashes bool
+
+	// StrictSlash makes trailing slashes significant: a route registered for
+	// "/users" does not match "/users/" and vice versa, and routes can be
+	// registered for both forms of a path. By default trailing slashes are
+	// ignored. It must be set before routes are registered.
+	StrictSlash bool
+
+	// LenientSlash makes a path match routes registered for it with or
+	// without a trailing slash. It is the default behavior, so the option
+	// only matters together with StrictSlash, that it partially overrides:
+	// routes for both forms of a path are kept apart, and the route for the
+	// same form as requested is preferred, but the route for the other form
+	// is used if there is no such route.
+	LenientSlash bool
+
+	// MaxRequestBody limits size of request bodies in bytes, if positive.
+	// Requests with larger bodies get 413 Request Entity Too Large when the
+	// router parses the form. Handlers get an error reading beyond the limit.
+	MaxRequestBody int64
+
+	// Unknown501 makes the router respond with 501 Not Implemented instead
+	// of 404 Not Found or 405 Method Not Allowed to requests with methods
+	// that are neither defined by HTTP nor listed in ExtensionMethods.
+	Unknown501 bool
+
+	// MaxSegments limits the number of slash-separated segments in request
+	// paths, if positive. Requests with longer paths get 414 Request-URI Too
+	// Long before route matching. Empty segments, such as the ones of
+	// duplicate and trailing slashes, are not counted.
+	MaxSegments int
+
+	// RejectInvalidUTF8 makes the router respond with 400 Bad Request to
+	// requests with paths that are not valid UTF-8 after percent-decoding,
+	// before route matching, so that parameters captured from the path are
+	// always valid UTF-8.
+	RejectInvalidUTF8 bool
+
+	// ParamSource sets which form values are added to Params. By default
+	// both URL query and request body values are added, like in r.Form.
+	ParamSource ParamSource
+
+	// OnTiming is called after the handler of a matched route returns or
+	// panics, with the matched pattern and the time it took to run the
+	// handler wrapped with middleware. Routes of mounted and host routers
+	// are reported by their own OnTiming.
+	OnTiming func(pattern string, d time.Duration)
+
+	// OnFinish is called after the handler of a matched route returns,
+	// unless the handler or middleware called Abort for the request or the
+	// handler panicked. It runs post-success side effects, like committing
+	// a transaction or recording an audit entry, after the response is
+	// written. Routes of mounted and host routers use their own OnFinish.
+	OnFinish func(w http.ResponseWriter, r *http.Request)
+
+	// ServerOptions is called for "OPTIONS *" requests, that ask about
+	// capabilities of the server rather than of a resource. If it is nil,
+	// the router responds with 200 OK and the Allow header listing OPTIONS
+	// and the methods of all routes, including mounted and host routers.
+	//
+	// http.Server answers "OPTIONS *" requests itself, so they reach the
+	// router only if DisableGeneralOptionsHandler of the server is set:
+	//
+	//	srv := &http.Server{Addr: ":8080", Handler: r, DisableGeneralOptionsHandler: true}
+	//	err := srv.ListenAndServe()
+	//
+	// http.ListenAndServe uses a server without it.
+	ServerOptions HandlerFunc
+
+	// Timeouts sets deadlines of the request contexts passed to handlers of
+	// matched routes by request method, for example a short one for GET and
+	// a longer one for POST. Handlers should stop working and return when
+	// the context is done. Requests with other methods have no deadline set
+	// by the router.
+	Timeouts map[string]time.Duration
+
+	// ParamsInContext makes the router store Params of matched routes in
+	// the request context, so that handlers ported from gorilla/mux can
+	// read them with Vars.
+	ParamsInContext bool
+
+	// WrapWriter is called for every request before anything else and
+	// returns the writer that the router, its middleware, handlers and
+	// PanicHandler write the response to, for example to buffer it or to
+	// record its size. It wraps the writer before middleware, so middleware
+	// that wraps the writer itself, like Logger, records what is written to
+	// the returned writer. The returned writer should implement Unwrap that
+	// returns the original writer, so that http.ResponseController can reach
+	// it, and pass WriteHeader calls through, so that the status code stays
+	// visible to the writers below it.
+	WrapWriter func(w http.ResponseWriter, r *http.Request) http.ResponseWriter
+
+	// DefaultHeaders are set on all responses of the router, including
+	// 404 Not Found and 405 Method Not Allowed, before routing, for example
+	// to add security headers like "X-Content-Type-Options: nosniff".
+	// Handlers and middleware may change or remove them.
+	DefaultHeaders http.Header
+
+	// ExtensionMethods lists methods not defined by HTTP, such as WebDAV
+	// "PROPFIND", that routes may be registered for.
+	ExtensionMethods []string
+}
+
+// Before adds a hook called for every request before route matching,
+// including requests that get 404 Not Found or 405 Method Not Allowed, for
+// example to log requests or set headers uniformly. If it returns false,
+// request handling stops: the hook is expected to have written the
+// response. Hooks run in the order they were added, after PreRoute, that
+// they are combined with. Like PreRoute, Before must not be called while
+// the router is serving requests.
+func (r *Router) Before(hook func(w http.ResponseWriter, r *http.Request) bool) {
+	prev := r.PreRoute
+	if prev == nil {
+		r.PreRoute = hook
+		return
+	}
+
+	r.PreRoute = func(w http.ResponseWriter, req *http.Request) bool {
+		return prev(w, req) && hook(w, req)
+	}
+}
+
+// anyMethod is the method of routes that handle requests with any method.
+const anyMethod = ""
+
+// Methods defined by HTTP.
+var standardMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"POST":    true,
+	"PUT":     true,
+	"PATCH":   true,
+	"DELETE":  true,
+	"CONNECT": true,
+	"OPTIONS": true,
+	"TRACE":   true,
 }
 
-type pathMethods map[string]HandlerFunc
+// A ParamSource is a source of form values added to Params.
+type ParamSource int
+
+// Sources of form values.
+const (
+	// ParamSourceBoth adds URL query and request body values, with body
+	// values first.
+	ParamSourceBoth ParamSource = iota
+
+	// ParamSourceQuery adds only URL query values. Request body is not
+	// parsed, so handlers can read it.
+	ParamSourceQuery
+
+	// ParamSourceBody adds only request body values, like in r.PostForm.
+	ParamSourceBody
+)
+
+// A RouteOption configures a route registered with Router.HandleWith.
+type RouteOption func(*route)
+
+type pathMethods map[string][]*route
+
+type route struct {
+	handler      HandlerFunc
+	chain        []HandlerFunc
+	contentTypes []string
+	predicates   []func(*http.Request) bool
+	priority     int
+	middleware   []middlewareEntry
+	meta         map[string]interface{}
+	name         string
+
+	// panicHandler overrides PanicHandler of the router if set.
+	panicHandler PanicHandlerFunc
+
+	// lastUsed is the time the route was registered or last selected for
+	// a request, in Unix nanoseconds, see Router.ExpireUnused.
+	lastUsed int64
+
+	// clientCert is set if the route requires verified client certificate.
+	clientCert bool
+
+	// rawBody is set if the request body is passed to the handler unread.
+	rawBody bool
+
+	// slash is set if the pattern has a trailing slash.
+	slash bool
+
+	// template is the pattern as it was registered.
+	template string
+}
 
 type pathData struct {
-	path    string
-	param   string
-	methods pathMethods
+	path     string
+	params   []string
+	segments []segment
+	methods  pathMethods
+	mount    *Router
+
+	// template is the pattern of the mounted router as it was registered.
+	template string
+
+	// conditional is set if any of the routes has predicates.
+	conditional bool
+
+	// priority is the highest priority of the routes.
+	priority int
+}
+
+// segment is a part of a pattern between slashes: either a static string
+// or a named parameter. A named parameter may be constrained by a regular
+// expression and followed by a literal suffix, that must be present at the
+// end of the segment. A catch-all parameter captures the rest of the path.
+type segment struct {
+	value    string
+	expr     string
+	re       *regexp.Regexp
+	suffix   string
+	param    bool
+	catchAll bool
 }
 
 // New initializes and returns a new router.
 func New() *Router {
-	return &Router{routes: map[string]*pathData{}}
+	r := &Router{}
+	r.table.Store(newRouteTable())
+
+	return r
 }
 
 // Get returns value for parameter with specified name.
@@ -62,17 +447,15 @@ func (ps Params) Get(name string) (string, bool) {
 // ServeHTTP handles the API request. It may perform some actions before
 // and/or after calling the handler function.
 func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Replace the writer if needed.
+	if router.WrapWriter != nil {
+		w = router.WrapWriter(w, r)
+	}
+
 	// Recover from panic.
 	defer func() {
 		if err := recover(); err != nil {
-			// Check if custom panic handler present.
-			if router.PanicHandler != nil {
-				// Call the custom panic handler.
-				router.PanicHandler(w, r, err)
-			} else {
-				// Write HTTP status code 500 Internal Server Error.
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+			router.recovered(r.Context(), w, r, err, router.PanicHandler)
 		}
 	}()
 
@@ -80,26 +463,184 @@ func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	router.doServeHTTP(w, r)
 }
 
+// recoverWith returns handler that recovers from panics in h with the
+// panic handler. Requests which handling panicked are aborted. The client
+// context is the request context before the router set the deadline of
+// Timeouts, so that handlers that panic after the deadline still respond.
+func (router *Router) recoverWith(client context.Context, h HandlerFunc, ph PanicHandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, ps Params) {
+		defer func() {
+			if err := recover(); err != nil {
+				Abort(r)
+				router.recovered(client, w, r, err, ph)
+			}
+		}()
+
+		h(w, r, ps)
+	}
+}
+
+// recovered responds to the request which handling panicked with the panic
+// handler, unless the client context is cancelled.
+func (router *Router) recovered(client context.Context, w http.ResponseWriter, r *http.Request, err interface{}, ph PanicHandlerFunc) {
+	// Do not respond if the client has gone away.
+	if client.Err() != nil {
+		if router.PanicLogger != nil {
+			router.PanicLogger(r, err)
+		}
+
+		return
+	}
+
+	// Check if custom panic handler present.
+	if ph != nil {
+		// Call the custom panic handler.
+		ph(w, r, err)
+	} else {
+		// Write HTTP status code 500 Internal Server Error.
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
 func (router *Router) doServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Set default headers, that handlers may override.
+	for k, v := range router.DefaultHeaders {
+		w.Header().Del(k)
+		for _, s := range v {
+			w.Header().Add(k, s)
+		}
+	}
+
+	// Respond without routing during maintenance.
+	if router.Maintenance.Load() && !router.maintenanceAllowed(r.URL.Path) {
+		router.maintenance(w, r)
+		return
+	}
+
+	// Call pre-routing hook if present.
+	if router.PreRoute != nil && !router.PreRoute(w, r) {
+		return
+	}
+
+	// Reject TRACE requests if disabled.
+	if router.DisableTrace && r.Method == "TRACE" {
+		router.rejectTrace(w, r)
+		return
+	}
+
+	// Respond to the server-wide OPTIONS request.
+	if r.Method == "OPTIONS" && r.URL.Path == "*" {
+		router.serverOptions(w, r)
+		return
+	}
+
+	// Reject paths with too many segments.
+	if router.MaxSegments > 0 && segmentCount(r.URL.Path) > router.MaxSegments {
+		// Set status code to 414 Request-URI Too Long.
+		w.WriteHeader(http.StatusRequestURITooLong)
+		return
+	}
+
+	// Reject paths that are not valid UTF-8 after decoding.
+	if router.RejectInvalidUTF8 && !utf8.ValidString(r.URL.Path) {
+		// Set status code to 400 Bad Request.
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Pass the request to the router of the host if it has a matching route.
+	if hr, ps := router.hostRouter(r); hr != nil {
+		h := func(w http.ResponseWriter, r *http.Request, ps Params) {
+			router.serveHost(w, r, hr, ps)
+		}
+
+		router.wrap(h, r.Method, normalizePath(r.URL.Path))(w, r, ps)
+		return
+	}
+
 	// Try to get path data.
-	pd, param := router.getPathData(r.URL.Path)
-	if pd == nil {
-		// Set status code to 404 Not Found.
-		w.WriteHeader(http.StatusNotFound)
+	res := router.lookup(r)
+	pd, values, rest := res.pd, res.values, res.rest
+
+	// Paths without routes for the method are not found if 405 is hidden.
+	if pd == nil || router.Hide405 && pd.mount == nil && res.routes == nil {
+		if router.notImplemented(w, r) {
+			return
+		}
+
+		router.notFound(w, r)
 		return
 	}
 
-	// Try to get handler function for requested method.
-	f, ok := pd.methods[r.Method]
-	if !ok {
-		// Create a list of allowed methods.
-		allow := ""
-		for m, _ := range pd.methods {
-			allow += m
+	// Redirect to the canonical path if needed.
+	if router.RedirectCanonical && (r.Method == "GET" || r.Method == "HEAD") {
+		if p := cleanSlashes(r.URL.Path); p != r.URL.Path {
+			u := *r.URL
+			u.Path, u.RawPath = p, ""
+			http.Redirect(w, r, u.RequestURI(), http.StatusMovedPermanently)
+
+			return
+		}
+	}
+
+	// Redirect to the lower case path if needed.
+	if router.RedirectLowercase && (r.Method == "GET" || r.Method == "HEAD") {
+		if p := router.lowercasePath(pd, r.URL.Path); p != r.URL.Path {
+			u := *r.URL
+			u.Path, u.RawPath = p, ""
+			http.Redirect(w, r, u.RequestURI(), http.StatusMovedPermanently)
+
+			return
+		}
+	}
+
+	// Take parameter values from the path in original case if needed.
+	if router.PreserveParamCase {
+		if segs := originalSegments(r.URL.Path); segs != nil {
+			values, rest = pd.originalValues(segs)
+		}
+	}
+
+	// Keep encoded slashes in the value of catch-all parameter if needed.
+	if router.EncodedSlashes && pd.catchAll() {
+		if v, ok := escapedRest(r.URL, len(pd.segments)-1, !router.PreserveParamCase); ok {
+			values[len(values)-1] = v
+		}
+	}
+
+	// Make the matched route available for middleware and handlers.
+	r = router.withRoute(r, pd)
+
+	// Limit the request body size.
+	if router.MaxRequestBody > 0 && r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, router.MaxRequestBody)
+	}
+
+	// Pass the request to the mounted router if needed.
+	if pd.mount != nil {
+		h := func(w http.ResponseWriter, r *http.Request, ps Params) {
+			router.serveMount(w, r, pd, values, rest)
+		}
+
+		router.wrap(h, r.Method, normalizePath(r.URL.Path))(w, r, pd.uriParams(values))
+		return
+	}
+
+	// Check if there are routes for requested method.
+	if res.routes == nil {
+		if router.notImplemented(w, r) {
+			return
 		}
 
 		// Set Allow header.
-		w.Header().Set("Allow", strings.TrimSuffix(allow, ", "))
+		allow := strings.Join(res.allow, ", ")
+		w.Header().Set("Allow", allow)
+
+		// Write problem document if needed.
+		if router.ProblemJSON {
+			writeProblem(w, http.StatusMethodNotAllowed, "Allowed methods: "+allow)
+			return
+		}
 
 		// Set status code to 405 Method Not Allowed.
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -107,169 +648,1855 @@ func (router *Router) doServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Choose the route that accepts the request body content type.
+	rt := selectRoute(res.routes, r)
+	if rt == nil {
+		// Set status code to 415 Unsupported Media Type.
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Reject requests without verified client certificate if required.
+	if rt.clientCert && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+		// Set status code to 403 Forbidden.
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// Record the use of the route for expiration.
+	if router.trackUse.Load() {
+		atomic.StoreInt64(&rt.lastUsed, time.Now().UnixNano())
+	}
+
+	// Make the template of the route available for the handler.
+	r = withTemplate(r, rt)
+
+	// Skip the request if the client has already gone away: there is no one
+	// to receive a response, so parsing the form and calling the handler
+	// would be wasted work.
+	if r.Context().Err() != nil {
+		return
+	}
+
+	// Decode parameters captured by own pattern.
+	own, err := router.decodeParams(pd.uriParams(values))
+	if err != nil {
+		// Set status code to 400 Bad Request.
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
 	// Parse form data.
-	err := r.ParseForm()
+	form, err := router.form(r, rt)
 	if err != nil {
+		// Set status code to 413 Request Entity Too Large if body exceeds
+		// the limit.
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
 		panic(err)
 	}
 
-	// Get form parameters.
-	params := Params(r.Form)
+	// Get form parameters. Form values are copied, so changes made to the
+	// parameters by handler do not affect the request form.
+	params := Params{}
+	for k, v := range form {
+		params[k] = append([]string(nil), v...)
+	}
 
-	// Add parameter sent as part of the URI if needed.
-	if pd.param != "" {
-		// Create new slice of values for parameter.
-		s := []string{param}
+	// Add parameters sent as part of the URI. Parameters captured by own
+	// pattern go first, then parameters captured by the routers this one is
+	// mounted to, then parameters taken from headers, and form values go
+	// last.
+	params.merge(router.headerParams(r))
+	params.merge(uriParams(r))
+	params.merge(own)
 
-		// Check if parameter name is used by form parameters.
-		if v, ok := params[pd.param]; ok {
-			// Insert the parameter sent as part of the URI at the beginning.
-			// This is needed so that Params.Get() will return it.
-			params[pd.param] = append(s, v...)
-		} else {
-			// Add new parameter name.
-			params[pd.param] = s
-		}
+	// Report handling time, even if the handler panics.
+	if router.OnTiming != nil {
+		start := time.Now()
+		defer func() {
+			router.OnTiming(MatchedPattern(r), time.Since(start))
+		}()
 	}
 
-	// Call the request handler.
-	f(w, r, params)
-}
+	// Set the deadline for the method.
+	client := r.Context()
+	if d, ok := router.Timeouts[r.Method]; ok && d > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
 
-// Handle sets an HTTP request handler for specific method and pattern.
-// Patterns support named parameters, for example:
-//
-//		err := Handle("GET", "/api/users/:id", usersByIdHandler)
-//
-// will pass id parameter to handler. Only one named parameter is
-// supported and it must be at the end of the URI.
-//
-func (r *Router) Handle(method string, pattern string, handler HandlerFunc) error {
-	// Parse pattern.
-	path, param, err := parsePattern(pattern)
-	if err != nil {
-		return err
+		r = r.WithContext(ctx)
 	}
 
-	// Try to get existing path data for the path.
-	pd, ok := r.routes[path]
-	if !ok {
-		// Create new path data.
-		pd = &pathData{
-			path:    path,
-			param:   param,
-			methods: pathMethods{},
-		}
+	// Make parameters available to the handlers that read them with Vars.
+	if router.ParamsInContext {
+		r = r.WithContext(context.WithValue(r.Context(), ParamsContextKey, params))
+	}
 
-		r.routes[path] = pd
+	// Handler marks failed requests, so that OnFinish is skipped.
+	if router.OnFinish != nil {
+		r = withAbort(r)
 	}
 
-	// Check if handler for the path is already registred.
-	if _, ok := pd.methods[method]; ok {
-		return ErrDuplicateHandler
+	// Call the request handler wrapped with middleware.
+	path := normalizePath(r.URL.Path)
+	h := rt.wrap(r.Method, path)
+	if rt.panicHandler != nil {
+		h = router.recoverWith(client, h, rt.panicHandler)
 	}
 
-	// Add handler for current method.
-	pd.methods[method] = handler
+	router.wrap(h, r.Method, path)(w, r, params)
 
-	return nil
+	if router.OnFinish != nil && !Aborted(r) {
+		router.OnFinish(w, r)
+	}
 }
 
-// Get adds handler for GET request.
-func (r *Router) Get(pattern string, handler HandlerFunc) error {
-	return r.Handle("GET", pattern, handler)
-}
+// form returns form values of the request from the sources set by
+// ParamSource.
+func (router *Router) form(r *http.Request, rt *route) (url.Values, error) {
+	// Request body is not read for query parameters and raw body routes.
+	if router.ParamSource == ParamSourceQuery || rt.rawBody {
+		return r.URL.Query(), nil
+	}
 
-// Put adds handler for PUT request.
-func (r *Router) Put(pattern string, handler HandlerFunc) error {
-	return r.Handle("PUT", pattern, handler)
-}
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
 
-// Post adds handler for POST request.
-func (r *Router) Post(pattern string, handler HandlerFunc) error {
-	return r.Handle("POST", pattern, handler)
+	if router.ParamSource == ParamSourceBody {
+		return r.PostForm, nil
+	}
+
+	return r.Form, nil
 }
 
-// Delete adds handler for DELETE request.
-func (r *Router) Delete(pattern string, handler HandlerFunc) error {
-	return r.Handle("DELETE", pattern, handler)
+// ParamFromHeader makes the router take the value of the parameter from
+// the request header if the pattern of the matched route, including mount
+// patterns, does not declare the parameter, for example:
+//
+//	ParamFromHeader("tenant", "X-Tenant")
+//
+// passes tenant from the path to the handlers of "/t/:tenant/users", and
+// from the X-Tenant header to the handlers of "/users". The path always
+// wins, so the header is ignored for the first route. The header value goes
+// before form values of the same name. Requests without the header get no
+// value.
+func (r *Router) ParamFromHeader(param string, header string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.loadConfig().clone()
+	c.fromHeaders[strings.ToLower(param)] = header
+	r.config.Store(c)
 }
 
-func normalizePath(p string) string {
-	// Return root path if empty string is received.
-	if len(p) == 0 {
-		return "/"
-	}
+// ParamDecoder sets the function that decodes values of the parameter
+// captured from the path by patterns of the router, for example to turn
+// a hashid into a numeric id:
+//
+//	ParamDecoder("id", func(s string) (string, error) {
+//		return decodeHashID(s)
+//	})
+//
+// Handlers get decoded values. If the function returns an error, the request
+// is answered with 400 Bad Request and the handler is not called. Values of
+// parameters captured by the routers this one is mounted to, and values
+// taken from headers and forms, are not decoded.
+func (r *Router) ParamDecoder(name string, fn func(string) (string, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Trim slashes at the end.
-	s := strings.TrimRight(p, "/")
+	c := r.loadConfig().clone()
+	c.decoders[strings.ToLower(name)] = fn
+	r.config.Store(c)
+}
 
-	// Replace backslashes with slashes (\ -> /).
-	s = strings.Replace(s, "\\", "/", -1)
+// decodeParams replaces values of the parameters that have decoders with
+// the decoded values.
+func (router *Router) decodeParams(ps Params) (Params, error) {
+	for name, fn := range router.loadConfig().decoders {
+		for i, v := range ps[name] {
+			d, err := fn(v)
+			if err != nil {
+				return nil, err
+			}
 
-	// Remove duplicate slashes (// -> /).
-	for strings.Contains(s, "//") {
-		s = strings.Replace(s, "//", "/", -1)
+			ps[name][i] = d
+		}
 	}
 
-	// Convert the string to lower.
-	s = strings.ToLower(s)
+	return ps, nil
+}
 
-	// Add leading slash if needed.
-	if p[0] != '/' {
-		s = "/" + s
+// headerParams returns parameters taken from the request headers, that
+// are not declared by the pattern of the matched route.
+func (router *Router) headerParams(r *http.Request) Params {
+	fromHeaders := router.loadConfig().fromHeaders
+	if len(fromHeaders) == 0 {
+		return nil
 	}
 
-	// Return normalized path.
-	return s
+	ps := Params{}
+	declared := ParamNames(r)
+	for param, header := range fromHeaders {
+		if v := r.Header.Get(header); v != "" && !containsString(declared, param) {
+			ps[param] = []string{v}
+		}
+	}
+
+	return ps
 }
 
-func parsePattern(pattern string) (string, string, error) {
-	// Normalize pattern.
-	path := normalizePath(pattern)
+// lookupResult holds the part of the route table needed to handle
+// a request.
+type lookupResult struct {
+	pd     *pathData
+	values []string
+	rest   string
+	routes []*route
+	allow  []string
+}
+
+// lookup finds the path data and the routes for the request path and method.
+// Routes with predicates that do not pass are treated as not registered, and
+// path data without other routes is skipped.
+func (router *Router) lookup(r *http.Request) lookupResult {
+	var res lookupResult
+	res.pd, res.values, res.rest = router.table.Load().getPathData(r.URL.Path, func(pd *pathData) bool {
+		// Mounted router handles all requests.
+		if pd.mount != nil {
+			return true
+		}
 
-	// Check if pattern contains parameter.
-	var param string
-	i := strings.Index(path, "/:")
-	if i >= 0 {
-		// Get parameter name.
-		param = path[i+2:]
+		res.routes, res.allow = pd.eligible(r, router.StrictSlash)
 
-		// Check parameter name.
-		if strings.ContainsAny(param, wrongParamNameChars) {
-			return "", "", ErrParameterName
+		// Try routes for the other form of the path if allowed.
+		if res.routes == nil && router.StrictSlash && router.LenientSlash {
+			res.routes, res.allow = pd.eligible(r, false)
 		}
 
-		// Remove parameter from the path, but keep "/:" at the end.
-		path = path[:i+2]
+		return res.routes != nil || len(res.allow) > 0
+	}, nil)
+
+	return res
+}
+
+// maintenanceAllowed reports whether the path is routed during maintenance.
+func (router *Router) maintenanceAllowed(p string) bool {
+	p = normalizePath(p)
+	for _, v := range router.MaintenancePaths {
+		if normalizePath(v) == p {
+			return true
+		}
 	}
 
-	// Return path and named parameter name.
-	return path, param, nil
+	return false
 }
 
-func (router *Router) getPathData(path string) (*pathData, string) {
-	// Normalize path.
-	path = normalizePath(path)
+// maintenance responds to the request during maintenance.
+func (router *Router) maintenance(w http.ResponseWriter, r *http.Request) {
+	if router.RetryAfter > 0 {
+		secs := (router.RetryAfter + time.Second - 1) / time.Second
+		w.Header().Set("Retry-After", strconv.Itoa(int(secs)))
+	}
 
-	// Try to get route without named parameter.
-	if pd, ok := router.routes[path]; ok {
-		// Return path data.
-		return pd, ""
+	// Call custom handler if present.
+	if router.MaintenanceHandler != nil {
+		router.MaintenanceHandler(w, r, Params{})
+		return
 	}
 
-	// Try to get route with named parameter.
-	if i := strings.LastIndex(path, "/"); i > 0 {
-		// Path with named parameter: remove parameter value and add "/:".
-		if pd, ok := router.routes[path[:i]+"/:"]; ok {
-			// Get parameter value.
-			p := path[i+1:]
+	// Set status code to 503 Service Unavailable.
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+// rejectTrace responds to TRACE request with 405 Method Not Allowed and the
+// other methods of the path in the Allow header, or like to unmatched
+// requests if Hide405 is set.
+func (router *Router) rejectTrace(w http.ResponseWriter, r *http.Request) {
+	// Do not reveal the methods of the path.
+	if router.Hide405 {
+		router.notFound(w, r)
+		return
+	}
 
-			// Return path data and named parameter name.
-			return pd, p
+	var allow []string
+	for _, m := range router.AllowedMethods(r.URL.Path) {
+		if m != "TRACE" && m != anyMethod {
+			allow = append(allow, m)
 		}
 	}
 
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+
+	// Set status code to 405 Method Not Allowed.
+	w.WriteHeader(http.StatusMethodNotAllowed)
+}
+
+// serverOptions responds to the "OPTIONS *" request.
+func (router *Router) serverOptions(w http.ResponseWriter, r *http.Request) {
+	// Call custom handler if present.
+	if router.ServerOptions != nil {
+		router.ServerOptions(w, r, Params{})
+		return
+	}
+
+	// Set Allow header.
+	methods := router.methods()
+	if len(methods) > 0 {
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+	}
+
+	// Set status code to 200 OK.
+	w.WriteHeader(http.StatusOK)
+}
+
+// methods returns sorted list of methods of all routes, including routes
+// of mounted routers and host routers.
+func (router *Router) methods() []string {
+	set := map[string]bool{"OPTIONS": true}
+	router.collectMethods(set)
+
+	methods := make([]string, 0, len(set))
+	for m := range set {
+		methods = append(methods, m)
+	}
+
+	sort.Strings(methods)
+
+	return methods
+}
+
+// collectMethods adds methods of all routes to the set.
+func (router *Router) collectMethods(set map[string]bool) {
+	for _, pd := range router.table.Load().routes {
+		if pd.mount != nil {
+			pd.mount.collectMethods(set)
+			continue
+		}
+
+		for m := range pd.methods {
+			if m != anyMethod {
+				set[m] = true
+			}
+		}
+	}
+
+	for _, hr := range router.loadConfig().hostRouters() {
+		hr.collectMethods(set)
+	}
+}
+
+// notImplemented responds with 501 Not Implemented if Unknown501 is set and
+// the request method is not defined by HTTP. Reports whether the response
+// was written.
+func (router *Router) notImplemented(w http.ResponseWriter, r *http.Request) bool {
+	if !router.Unknown501 || router.knownMethod(r.Method) {
+		return false
+	}
+
+	// Set status code to 501 Not Implemented.
+	w.WriteHeader(http.StatusNotImplemented)
+
+	return true
+}
+
+// knownMethod reports whether the method is defined by HTTP or listed in
+// extension methods of the router.
+func (router *Router) knownMethod(method string) bool {
+	if standardMethods[method] {
+		return true
+	}
+
+	for _, m := range router.ExtensionMethods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notFound responds to the request that did not match any route.
+func (router *Router) notFound(w http.ResponseWriter, r *http.Request) {
+	// Call custom handler if present.
+	if router.NotFound != nil {
+		router.NotFound(w, r, Params{})
+		return
+	}
+
+	// Write problem document if needed.
+	if router.ProblemJSON && len(router.NotFoundBody) == 0 {
+		writeProblem(w, http.StatusNotFound, "")
+		return
+	}
+
+	// Set content type of the static body.
+	if router.NotFoundContentType != "" {
+		w.Header().Set("Content-Type", router.NotFoundContentType)
+	}
+
+	// Set status code to 404 Not Found.
+	w.WriteHeader(http.StatusNotFound)
+
+	// Write static body if present.
+	if len(router.NotFoundBody) > 0 {
+		w.Write(router.NotFoundBody)
+	}
+}
+
+// Handle sets an HTTP request handler for specific method and pattern.
+// Patterns support named parameters, for example:
+//
+//	err := Handle("GET", "/api/users/:id", usersByIdHandler)
+//
+// will pass id parameter to handler. A named parameter takes a whole path
+// segment, and a pattern may contain several of them:
+//
+//	err := Handle("GET", "/api/users/:id/posts/:post", postHandler)
+//
+// A named parameter may be constrained by a regular expression in
+// parentheses, that must match the whole segment:
+//
+//	err := Handle("GET", "/api/:version(v1|v2)/users/:id([0-9]+)", userHandler)
+//
+// Paths are matched in lower case, so expressions should use lower case
+// letters. Instead of an expression, a parameter may declare one of the
+// built-in types after a vertical bar: "int", "float", "uuid" or "slug":
+//
+//	err := Handle("GET", "/api/users/:id|int", userHandler)
+//
+// Requests with segments that are not valid values of the type get 404 Not
+// Found. A named parameter may also be followed by a literal suffix
+// starting with a dot, for example "/reports/:id.json" matches
+// "/reports/7.json" with id "7", but does not match "/reports/7.xml".
+//
+// The last segment of a pattern may be a catch-all parameter that captures
+// the rest of the path, including slashes. It also matches when the rest is
+// empty, so "/*path" matches every path, including "/":
+//
+//	err := Handle("GET", "/*path", spaIndexHandler)
+//
+// Named parameters before a catch-all parameter capture single segments as
+// usual, so "/a/:first/*rest" matches "/a/x/y/z" with first "x" and rest
+// "y/z", and "/a/x" with first "x" and empty rest, but does not match "/a".
+//
+// If a path matches several patterns, static segments win over named
+// parameters, and named parameters win over catch-all parameters, starting
+// with the leftmost segment. See WithPriority to override this order.
+// Patterns are ordered by these rules alone, so the same routes match
+// the same way regardless of the order in which they are registered. The
+// only exception are handlers with predicates for the same method and
+// pattern, which are tried in the order of registration, see HandleIf.
+// Host routers do not depend on the order in which they are added either,
+// see Host.
+//
+// The method must be defined by HTTP or listed in ExtensionMethods,
+// otherwise ErrMethod is returned. An empty method registers the handler
+// for any method: it handles requests with methods that have no handlers
+// registered for the same pattern, so the path never responds with 405
+// Method Not Allowed.
+func (r *Router) Handle(method string, pattern string, handler HandlerFunc) error {
+	return r.HandleWith(method, pattern, handler)
+}
+
+// HandleWith sets an HTTP request handler for specific method and pattern
+// like Handle does, but additionally applies route options, for example:
+//
+//	err := HandleWith("POST", "/api/upload", jsonHandler, WithContentType("application/json"))
+//
+// Several handlers can be registered for the same method and pattern as
+// long as their options do not overlap.
+func (r *Router) HandleWith(method string, pattern string, handler HandlerFunc, opts ...RouteOption) error {
+	err := r.handleWith(method, pattern, handler, opts)
+	if err != nil {
+		r.addError(method, pattern, err)
+	}
+
+	return err
+}
+
+// handleWith registers the route for Router.HandleWith.
+func (r *Router) handleWith(method string, pattern string, handler HandlerFunc, opts []RouteOption) error {
+	// Create route and apply options.
+	rt := newRoute(pattern, handler)
+	for _, opt := range opts {
+		opt(rt)
+	}
+
+	// Parse pattern.
+	pd, err := r.parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	return r.addRoute(method, pd, rt)
+}
+
+// newRoute returns the route of the handler without options.
+func newRoute(pattern string, handler HandlerFunc) *route {
+	return &route{handler: handler, slash: hasTrailingSlash(pattern), template: pattern, lastUsed: time.Now().UnixNano()}
+}
+
+// addRoute adds the route for the method to the path data registered for
+// the same path, or to the new path data if there is none.
+func (r *Router) addRoute(method string, pd *pathData, rt *route) error {
+	// Check method.
+	if method != anyMethod && !r.knownMethod(method) {
+		return ErrMethod
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Route table is changed on a copy, so that requests being served are
+	// not affected.
+	t := r.table.Load().clone()
+	if err := r.insertRoute(t, method, pd, rt); err != nil {
+		return err
+	}
+
+	r.table.Store(t)
+
+	return nil
+}
+
+// insertRoute adds the route for the method to the route table, that is
+// not used by the router yet. The caller must hold the lock.
+func (r *Router) insertRoute(t *routeTable, method string, pd *pathData, rt *route) error {
+	// Try to get existing path data for the path.
+	params := pd.params
+	if v, ok := t.routes[pd.path]; ok {
+		pd = v.clone()
+		t.replace(v, pd)
+	} else {
+		pd.priority = rt.priority
+		t.add(pd)
+	}
+
+	// Mounted router handles all methods for the path.
+	if pd.mount != nil {
+		return ErrDuplicateHandler
+	}
+
+	// Check if handler for the path is already registred.
+	for _, v := range pd.methods[method] {
+		if v.overlaps(rt) && (!r.StrictSlash || v.slash == rt.slash) {
+			// Explain the collision of patterns with different names.
+			if !equalStrings(params, pd.params) {
+				return fmt.Errorf("%w: pattern differs from %#q only in parameter names, which are not used for matching", ErrDuplicateHandler, pd.pattern())
+			}
+
+			return ErrDuplicateHandler
+		}
+	}
+
+	// Raise priority of the path data if needed.
+	if rt.priority > pd.priority {
+		pd.priority = rt.priority
+		t.sort()
+	}
+
+	// Add route for current method.
+	pd.methods[method] = append(pd.methods[method], rt)
+	if len(rt.predicates) > 0 {
+		pd.conditional = true
+	}
+
+	return nil
+}
+
+// WithPriority sets priority of the route pattern. When a path matches
+// several patterns, the pattern with higher priority wins regardless of
+// the specificity rules described for Handle, which are only used to choose
+// among the patterns with equal priority. Default priority is 0, and
+// negative priorities make a pattern lose to the default ones. Priority
+// applies to the pattern as a whole: if routes for different methods of the
+// same pattern set different priorities, the highest one is used.
+func WithPriority(n int) RouteOption {
+	return func(rt *route) {
+		rt.priority = n
+	}
+}
+
+// WithName sets the name of the route, that identifies its handler when
+// routes are exported with ExportRoutes and imported with ImportRoutes.
+// Names are reported by Routes and have no effect on request handling.
+func WithName(name string) RouteOption {
+	return func(rt *route) {
+		rt.name = name
+	}
+}
+
+// WithMeta attaches metadata to the route, like a summary, tags or required
+// authorization scopes, for example to generate documentation:
+//
+//	err := HandleWith("GET", "/api/users", usersHandler, WithMeta(map[string]interface{}{
+//		"summary": "List users",
+//	}))
+//
+// Metadata is returned by Routes and has no effect on request handling.
+// Options applied later add to the metadata set by earlier ones.
+func WithMeta(meta map[string]interface{}) RouteOption {
+	return func(rt *route) {
+		if rt.meta == nil {
+			rt.meta = map[string]interface{}{}
+		}
+
+		for k, v := range meta {
+			rt.meta[k] = v
+		}
+	}
+}
+
+// HandleIf sets an HTTP request handler for specific method and pattern that
+// is used only when the predicate returns true for the request, for example
+// to roll out a new implementation gradually:
+//
+//	err := HandleIf(inBeta, "GET", "/api/search", newSearchHandler)
+//	err = Handle("GET", "/api/search", searchHandler)
+//
+// When the predicate returns false, the router behaves as if the handler was
+// not registered: another handler for the same method and pattern is used,
+// or another pattern that matches the path, or 405 Method Not Allowed if
+// the path has handlers for other methods, or 404 Not Found. Methods of the
+// handlers with failing predicates are not listed in the Allow header. If
+// predicates of several handlers return true, the handler registered first
+// is used.
+//
+// Predicates may register or remove routes, which affects the following
+// requests.
+func (r *Router) HandleIf(predicate func(*http.Request) bool, method string, pattern string, handler HandlerFunc) error {
+	return r.HandleWith(method, pattern, handler, When(predicate))
+}
+
+// When restricts route to requests for which all the predicates return
+// true, for example:
+//
+//	err := HandleWith("GET", "/api/search", newSearchHandler, When(inBeta, fromOffice))
+//
+// Options applied later add to the predicates of earlier ones. Route with
+// predicates is handled like a route registered with HandleIf: when some
+// predicate returns false, the router behaves as if the route was not
+// registered, and it wins over the routes without predicates registered
+// for the same method and pattern when all its predicates return true.
+func When(predicates ...func(*http.Request) bool) RouteOption {
+	return func(rt *route) {
+		rt.predicates = append(rt.predicates, predicates...)
+	}
+}
+
+// RestParam is the name of the parameter with the rest of the path for
+// handlers registered with Router.HandlePrefix.
+const RestParam = "*"
+
+// HandlePrefix sets an HTTP request handler for specific method and all
+// paths starting with the prefix, for example:
+//
+//	err := HandlePrefix("GET", "/legacy", legacyHandler)
+//
+// handles "/legacy" with the RestParam parameter set to "" and
+// "/legacy/anything/here" with it set to "/anything/here". The prefix may
+// contain named parameters. The handler is registered like a route with
+// a catch-all parameter, so any other route that matches the path wins.
+func (r *Router) HandlePrefix(method string, prefix string, handler HandlerFunc) error {
+	h := func(w http.ResponseWriter, req *http.Request, ps Params) {
+		// Add leading slash to the rest of the path.
+		if v := ps[RestParam]; len(v) > 0 && v[0] != "" {
+			v[0] = "/" + v[0]
+		}
+
+		handler(w, req, ps)
+	}
+
+	return r.HandleWith(method, strings.TrimRight(prefix, "/")+"/*"+RestParam, h, func(rt *route) {
+		rt.template = prefix
+	})
+}
+
+// WithContentType restricts route to requests with one of the specified
+// body content types. Parameters of the request Content-Type header, like
+// charset, are ignored during matching. If none of the routes registered for
+// the path and method accepts the request content type, the router responds
+// with 415 Unsupported Media Type.
+func WithContentType(types ...string) RouteOption {
+	return func(rt *route) {
+		for _, t := range types {
+			rt.contentTypes = append(rt.contentTypes, mediaType(t))
+		}
+	}
+}
+
+// WithRawBody makes the router pass the request body to the handler
+// unread, for example to stream a large upload with r.MultipartReader.
+// Only URL query values are added to Params, regardless of ParamSource.
+func WithRawBody() RouteOption {
+	return func(rt *route) {
+		rt.rawBody = true
+	}
+}
+
+// WithPanicHandler sets the handler of panics in the route handler and its
+// middleware, that is used instead of PanicHandler of the router, for
+// example to respond with an HTML error page from some routes and with JSON
+// from the others. Panics in router middleware, that wraps the route
+// middleware, are still handled by PanicHandler.
+func WithPanicHandler(ph PanicHandlerFunc) RouteOption {
+	return func(rt *route) {
+		rt.panicHandler = ph
+	}
+}
+
+// RequireClientCert makes the router respond with 403 Forbidden to requests
+// for the route that were not received over TLS with a client certificate
+// verified by the server, as configured by ClientAuth of tls.Config.
+// Requests received without TLS are rejected too. Unlike a predicate, the
+// requirement does not make the router try other routes.
+func RequireClientCert() RouteOption {
+	return func(rt *route) {
+		rt.clientCert = true
+	}
+}
+
+// WithExtensions restricts route to paths with one of the specified file
+// extensions, compared case-insensitively, for example:
+//
+//	err := HandleWith("GET", "/images/*path", imageHandler, WithExtensions(".jpg", ".png", ".webp"))
+//
+// Paths with other extensions are treated as not matching the route, like
+// with a failing predicate of HandleIf, so they usually get 404 Not Found.
+func WithExtensions(exts ...string) RouteOption {
+	allowed := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		allowed[strings.ToLower(ext)] = true
+	}
+
+	return When(func(r *http.Request) bool {
+		return allowed[strings.ToLower(path.Ext(r.URL.Path))]
+	})
+}
+
+// Get adds handler for GET request.
+func (r *Router) Get(pattern string, handler HandlerFunc) error {
+	return r.Handle("GET", pattern, handler)
+}
+
+// Put adds handler for PUT request.
+func (r *Router) Put(pattern string, handler HandlerFunc) error {
+	return r.Handle("PUT", pattern, handler)
+}
+
+// Post adds handler for POST request.
+func (r *Router) Post(pattern string, handler HandlerFunc) error {
+	return r.Handle("POST", pattern, handler)
+}
+
+// Delete adds handler for DELETE request.
+func (r *Router) Delete(pattern string, handler HandlerFunc) error {
+	return r.Handle("DELETE", pattern, handler)
+}
+
+// Mount passes all requests with path starting with pattern to the sub
+// router. The matched prefix is removed from the request path before the
+// sub router handles the request. Pattern may contain named parameters,
+// for example:
+//
+//	err := Mount("/tenants/:tenant", tenantRouter)
+//
+// Parameters captured by the pattern are visible to the handlers of the sub
+// router. If the sub router pattern captures a parameter with the same name,
+// the sub router value goes first, so Params.Get returns it.
+//
+// Routes registered directly for paths under the pattern win over the
+// mounted router.
+func (r *Router) Mount(pattern string, sub *Router) error {
+	err := r.mount(pattern, sub)
+	if err != nil {
+		r.addError("", pattern, err)
+	}
+
+	return err
+}
+
+// mount mounts the sub router for Router.Mount.
+func (r *Router) mount(pattern string, sub *Router) error {
+	// Parse pattern.
+	pd, err := r.parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	// Mounted path data is stored separately from the routes with the
+	// same pattern.
+	pd.path = strings.TrimSuffix(pd.path, "/") + "/..."
+	pd.mount = sub
+	pd.template = pattern
+
+	return r.addMount(pd)
+}
+
+// addMount adds the path data of a mounted router to the route table.
+func (r *Router) addMount(pd *pathData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check if a router was already mounted for the pattern.
+	t := r.table.Load().clone()
+	if _, ok := t.routes[pd.path]; ok {
+		return ErrDuplicateHandler
+	}
+
+	t.add(pd)
+	r.table.Store(t)
+
+	return nil
+}
+
+// Walk calls fn for every registered method and pattern combination.
+// Routes are visited in lexical order of their patterns, and methods of
+// the same pattern are visited in lexical order too. Handlers registered
+// for the same method and pattern with different options are visited in
+// registration order, and handlers of a chain are visited in the chain
+// order. Routes of mounted routers are visited with the mount
+// pattern prepended. Routes for any method are visited with the empty
+// method.
+//
+// Walk visits the routes registered at the moment it was called, so fn may
+// register or remove routes.
+func (r *Router) Walk(fn WalkFunc) error {
+	for _, e := range r.walkEntries() {
+		// Visit every handler of a chain.
+		handlers := e.route.chain
+		if handlers == nil {
+			handlers = []HandlerFunc{e.route.handler}
+		}
+
+		for _, h := range handlers {
+			if err := fn(e.method, e.pattern, h); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Routes returns all registered routes in the order they are visited by
+// Walk, with names set by WithName and metadata set by WithMeta. Metadata
+// is shared with the routes, so it must not be modified. Handlers of the
+// routes registered with HandleChain run the whole chain. Options of the
+// routes are not returned.
+func (r *Router) Routes() []RouteInfo {
+	var routes []RouteInfo
+	for _, e := range r.walkEntries() {
+		routes = append(routes, RouteInfo{Method: e.method, Pattern: e.pattern, Name: e.route.name, Handler: e.route.handler, Meta: e.route.meta})
+	}
+
+	return routes
+}
+
+// StaticRoutes returns sorted normalized paths of the routes that accept GET
+// requests and have no named or catch-all parameters, for example to build
+// a sitemap. Routes of mounted routers are included if the mount pattern has
+// no parameters. Every path is listed once.
+func (r *Router) StaticRoutes() []string {
+	var paths []string
+	for _, e := range r.walkEntries() {
+		if e.method != "GET" && e.method != anyMethod || !isStaticPattern(e.pattern) {
+			continue
+		}
+
+		paths = append(paths, e.pattern)
+	}
+
+	// Skip routes with the same path, that are not always next to each
+	// other in the order of Walk, for example with mounted routers.
+	sort.Strings(paths)
+
+	return slices.Compact(paths)
+}
+
+// isStaticPattern reports whether normalized pattern has no parameters.
+func isStaticPattern(pattern string) bool {
+	return !strings.Contains(pattern, "/:") && !strings.Contains(pattern, "/*")
+}
+
+// A RouteInfo describes a route returned by Router.Routes.
+type RouteInfo struct {
+	Method  string
+	Pattern string
+	Name    string
+	Handler HandlerFunc
+	Meta    map[string]interface{}
+}
+
+// walkEntry is a route visited by Router.Walk.
+type walkEntry struct {
+	method  string
+	pattern string
+	route   *route
+}
+
+// walkEntries returns all routes in the order they are visited by Walk.
+func (r *Router) walkEntries() []walkEntry {
+	var entries []walkEntry
+	for _, pd := range r.table.Load().sorted() {
+		// Add routes of the mounted router with the mount pattern prefix.
+		if pd.mount != nil {
+			for _, e := range pd.mount.walkEntries() {
+				e.pattern = joinPattern(pd.pattern(), e.pattern)
+				entries = append(entries, e)
+			}
+
+			continue
+		}
+
+		// Add every method handler.
+		for _, m := range pd.allowedMethods() {
+			for _, rt := range pd.methods[m] {
+				// Trailing slash is a part of the pattern in strict mode.
+				pattern := pd.pattern()
+				if r.StrictSlash && rt.slash {
+					pattern += "/"
+				}
+
+				entries = append(entries, walkEntry{m, pattern, rt})
+			}
+		}
+	}
+
+	return entries
+}
+
+// AllowedMethods returns sorted list of methods of the routes that handle
+// the request path, for example "/users/7" for routes of "/users/:id".
+// Paths are matched like request paths, including routes of mounted
+// routers, but not of host routers. Routes for any method are listed with
+// the empty method, like in Walk, and routes registered with HandleIf are
+// listed regardless of their predicates. Returns nil if no route matches
+// the path.
+func (r *Router) AllowedMethods(path string) []string {
+	pd, _, rest := r.table.Load().getPathData(path, nil, nil)
+	switch {
+	case pd == nil:
+		return nil
+	case pd.mount != nil:
+		return pd.mount.AllowedMethods(rest)
+	}
+
+	return pd.allowedMethods()
+}
+
+// Summary returns the number of distinct registered paths and the total
+// number of handlers registered for them. Routes of mounted routers are
+// counted too.
+func (r *Router) Summary() (paths int, handlers int) {
+	for _, pd := range r.table.Load().routes {
+		// Count routes of the mounted router.
+		if pd.mount != nil {
+			p, h := pd.mount.Summary()
+			paths += p
+			handlers += h
+
+			continue
+		}
+
+		paths++
+		for _, rs := range pd.methods {
+			handlers += len(rs)
+		}
+	}
+
+	return paths, handlers
+}
+
+// Remove removes handlers registered for the method and pattern. The pattern
+// is matched the same way as in Handle, so parameter names are not taken
+// into account. Returns false if there were no such handlers.
+//
+// If the path has handlers for other methods, requests with the removed
+// method get 405 Method Not Allowed, otherwise 404 Not Found.
+func (r *Router) Remove(method string, pattern string) bool {
+	// Parse pattern.
+	key, err := r.parsePattern(pattern)
+	if err != nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Try to get path data with the method.
+	t := r.table.Load().clone()
+	v, ok := t.routes[key.path]
+	if !ok || v.mount != nil || v.methods[method] == nil {
+		return false
+	}
+
+	pd := v.clone()
+	delete(pd.methods, method)
+
+	// Remove path data when the last method is removed.
+	if len(pd.methods) == 0 {
+		t.remove(v)
+	} else {
+		pd.resetFlags()
+		t.replace(v, pd)
+		t.sort()
+	}
+
+	r.table.Store(t)
+
+	return true
+}
+
+// pattern returns normalized pattern the path data was registered with.
+func (pd *pathData) pattern() string {
+	s := ""
+	for _, seg := range pd.segments {
+		s += "/" + seg.label()
+	}
+
+	if s == "" {
+		return "/"
+	}
+
+	return s
+}
+
+// uriParams returns parameters captured from the URI by the pattern.
+func (pd *pathData) uriParams(values []string) Params {
+	ps := Params{}
+	for i, name := range pd.params {
+		ps[name] = append(ps[name], values[i])
+	}
+
+	return ps
+}
+
+// match checks if path segments match the pattern and returns values of the
+// named parameters. For mounted routers it also returns the rest of the path.
+func (pd *pathData) match(segs []string) ([]string, string, bool) {
+	// Check number of segments.
+	n := len(pd.segments)
+	switch {
+	case pd.catchAll():
+		// Catch-all parameter may capture no segments at all.
+		if len(segs) < n-1 {
+			return nil, "", false
+		}
+	case len(segs) < n || pd.mount == nil && len(segs) != n:
+		return nil, "", false
+	}
+
+	values := make([]string, 0, len(pd.params))
+	for i, seg := range pd.segments {
+		if seg.catchAll {
+			// Capture the rest of the path.
+			values = append(values, strings.Join(segs[i:], "/"))
+		} else if seg.param {
+			v, ok := seg.accept(segs[i])
+			if !ok {
+				return nil, "", false
+			}
+
+			// Capture parameter value.
+			values = append(values, v)
+		} else if seg.value != segs[i] {
+			return nil, "", false
+		}
+	}
+
+	// Get the rest of the path for mounted router.
+	rest := ""
+	if pd.mount != nil {
+		rest = "/" + strings.Join(segs[len(pd.segments):], "/")
+	}
+
+	return values, rest, true
+}
+
+// accept checks that the path segment matches the named parameter segment
+// and returns the parameter value.
+func (seg segment) accept(v string) (string, bool) {
+	// Check literal suffix of the segment.
+	if seg.suffix != "" {
+		if len(v) <= len(seg.suffix) || !strings.HasSuffix(v, seg.suffix) {
+			return "", false
+		}
+
+		v = v[:len(v)-len(seg.suffix)]
+	}
+
+	// Check regular expression of the parameter.
+	if seg.re != nil && !seg.re.MatchString(v) {
+		return "", false
+	}
+
+	return v, true
+}
+
+// morePreferred reports whether the path data should be tried before other
+// path data when matching the path. Path data with higher priority is always
+// preferred. Otherwise segments are compared from left to right: a static
+// segment is preferred over a named parameter with regular expression or
+// literal suffix, which is preferred over a named parameter without
+// constraints, and the end of the pattern is preferred over a catch-all
+// parameter, which is preferred over the rest of the path handled by
+// a mounted router.
+func (pd *pathData) morePreferred(other *pathData) bool {
+	if pd.priority != other.priority {
+		return pd.priority > other.priority
+	}
+
+	for i := 0; ; i++ {
+		a, b := pd.rank(i), other.rank(i)
+		if a != b {
+			return a > b
+		}
+
+		// Use path as a tie breaker, so that order is always deterministic.
+		if a <= rankEnd {
+			return pd.path < other.path
+		}
+	}
+}
+
+// Ranks of pattern segments used to order the path data for matching.
+const (
+	rankMount = iota
+	rankCatchAll
+	rankEnd
+	rankParam
+	rankConstrained
+	rankStatic
+)
+
+// rank returns rank of i-th segment of the pattern.
+func (pd *pathData) rank(i int) int {
+	switch {
+	case i >= len(pd.segments) && pd.mount != nil:
+		return rankMount
+	case i >= len(pd.segments):
+		return rankEnd
+	case pd.segments[i].catchAll:
+		return rankCatchAll
+	case pd.segments[i].param && pd.segments[i].constraint() != "":
+		return rankConstrained
+	case pd.segments[i].param:
+		return rankParam
+	default:
+		return rankStatic
+	}
+}
+
+// label returns normalized pattern of the segment without the slash.
+func (seg segment) label() string {
+	if seg.catchAll {
+		return "*" + seg.value
+	} else if seg.param {
+		return ":" + seg.value + seg.constraint()
+	}
+
+	return seg.value
+}
+
+// constraint returns regular expression and literal suffix of the segment
+// in the pattern syntax.
+func (seg segment) constraint() string {
+	if seg.expr == "" {
+		return seg.suffix
+	}
+
+	return "(" + seg.expr + ")" + seg.suffix
+}
+
+// catchAll reports whether the pattern ends with a catch-all parameter.
+func (pd *pathData) catchAll() bool {
+	n := len(pd.segments)
+	return n > 0 && pd.segments[n-1].catchAll
+}
+
+// static reports whether the pattern has neither named parameters nor
+// mounted router.
+func (pd *pathData) static() bool {
+	return len(pd.params) == 0 && pd.mount == nil
+}
+
+// serveMount passes the request to the mounted router.
+func (router *Router) serveMount(w http.ResponseWriter, r *http.Request, pd *pathData, values []string, rest string) {
+	// Make parameters captured by this router and the routers it is mounted
+	// to available for the mounted router.
+	ps := Params{}
+	ps.merge(uriParams(r))
+	ps.merge(pd.uriParams(values))
+	ctx := context.WithValue(r.Context(), paramsContextKey, ps)
+
+	// Remove the matched prefix from the request path.
+	u := *r.URL
+	u.Path = rest
+	u.RawPath = ""
+
+	r = r.WithContext(ctx)
+	r.URL = &u
+
+	// Handle the request by the mounted router.
+	pd.mount.ServeHTTP(w, r)
+}
+
+// ResourceNotFound responds to the request that matched a route, but asks
+// for a resource that does not exist, with MissingResource handler of the
+// router that matched the route, for example:
+//
+//	user, ok := users[id]
+//	if !ok {
+//		ResourceNotFound(w, r, ps)
+//		return
+//	}
+//
+// Unlike NotFound handler of the router, that is called for paths that do
+// not match any route, MissingResource is called for paths that do. If the
+// router has no MissingResource handler, or the request was not matched by
+// a router, it responds with 404 Not Found without body.
+func ResourceNotFound(w http.ResponseWriter, r *http.Request, ps Params) {
+	if ri, ok := r.Context().Value(routeContextKey).(*routeInfo); ok && ri.router.MissingResource != nil {
+		ri.router.MissingResource(w, r, ps)
+		return
+	}
+
+	// Set status code to 404 Not Found.
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// MatchedPattern returns normalized pattern of the route that matched the
+// request. For routes of mounted routers the pattern includes the mount
+// pattern. Returns empty string if the request was not matched by a router.
+func MatchedPattern(r *http.Request) string {
+	return PatternFromContext(r.Context())
+}
+
+// PatternFromContext returns the pattern that MatchedPattern returns for
+// a request with the context, for code that has only the context, like
+// database or logging hooks.
+func PatternFromContext(ctx context.Context) string {
+	if ri, ok := ctx.Value(routeContextKey).(*routeInfo); ok {
+		return ri.pattern
+	}
+
+	return ""
+}
+
+// ParamsFromContext returns Params stored in the context under
+// ParamsContextKey. Returns false if there are none, which is always the
+// case if ParamsInContext is not set.
+func ParamsFromContext(ctx context.Context) (Params, bool) {
+	ps, ok := ctx.Value(ParamsContextKey).(Params)
+	return ps, ok
+}
+
+// Template returns the pattern of the route that handles the request as it
+// was passed to Handle, unlike MatchedPattern, that returns the normalized
+// pattern. It lets handlers generate links or documentation for their own
+// route. For routes of mounted routers the mount pattern is prepended.
+// Returns empty string if the request was not matched by a router.
+func Template(r *http.Request) string {
+	if ri, ok := r.Context().Value(routeContextKey).(*routeInfo); ok {
+		return ri.template
+	}
+
+	return ""
+}
+
+// ParamNames returns names of the parameters declared by the pattern of the
+// route that matched the request, in the order of declaration. For routes of
+// mounted routers the names declared by the mount pattern go first. Returns
+// nil if the request was not matched by a router.
+func ParamNames(r *http.Request) []string {
+	if ri, ok := r.Context().Value(routeContextKey).(*routeInfo); ok {
+		return append([]string(nil), ri.params...)
+	}
+
+	return nil
+}
+
+// withRoute returns request with information about the matched route
+// stored in context.
+func (router *Router) withRoute(r *http.Request, pd *pathData) *http.Request {
+	ri := &routeInfo{pattern: pd.pattern(), params: pd.params, template: pd.template, levels: []int{len(pd.params)}, router: router}
+
+	// Add information about the route of the outer router.
+	if outer, ok := r.Context().Value(routeContextKey).(*routeInfo); ok {
+		ri.pattern = joinPattern(outer.pattern, ri.pattern)
+		ri.params = append(append([]string(nil), outer.params...), ri.params...)
+		ri.template = joinPattern(outer.template, ri.template)
+		ri.levels = append(append([]int(nil), outer.levels...), ri.levels...)
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), routeContextKey, ri))
+}
+
+// withTemplate returns request with the template of the selected route
+// added to information about the matched route.
+func withTemplate(r *http.Request, rt *route) *http.Request {
+	ri, ok := r.Context().Value(routeContextKey).(*routeInfo)
+	if !ok {
+		return r
+	}
+
+	c := *ri
+	c.template = joinPattern(ri.template, rt.template)
+
+	return r.WithContext(context.WithValue(r.Context(), routeContextKey, &c))
+}
+
+// joinPattern returns pattern of a mounted router route with the mount
+// pattern prefix.
+func joinPattern(prefix string, pattern string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if pattern == "/" && prefix != "" {
+		return prefix
+	}
+
+	return prefix + pattern
+}
+
+// uriParams returns parameters captured from the URI by the routers the
+// request was passed through.
+func uriParams(r *http.Request) Params {
+	ps, _ := r.Context().Value(paramsContextKey).(Params)
+	return ps
+}
+
+// merge inserts values of other parameters before the existing values of
+// the same parameters.
+func (ps Params) merge(other Params) {
+	for name, v := range other {
+		ps[name] = append(append([]string(nil), v...), ps[name]...)
+	}
+}
+
+// allowedMethods returns sorted list of methods registered for the path.
+func (pd *pathData) allowedMethods() []string {
+	methods := make([]string, 0, len(pd.methods))
+	for m := range pd.methods {
+		methods = append(methods, m)
+	}
+
+	sort.Strings(methods)
+
+	return methods
+}
+
+// eligible returns routes for the request method with passing predicates,
+// or routes for any method if there are none. If there are no such routes
+// either, it also returns sorted list of methods that have routes with
+// passing predicates. If strict is true, routes registered for the path
+// with a trailing slash accept only requests with a trailing slash and vice
+// versa, unless the pattern ends with a catch-all parameter.
+func (pd *pathData) eligible(r *http.Request, strict bool) ([]*route, []string) {
+	strict = strict && !pd.catchAll()
+	slash := hasTrailingSlash(r.URL.Path)
+
+	// Path data without predicates does not need filtering.
+	if !pd.conditional && !strict {
+		if routes := pd.methods[r.Method]; routes != nil {
+			return routes, nil
+		}
+
+		if routes := pd.methods[anyMethod]; routes != nil {
+			return routes, nil
+		}
+
+		return nil, pd.allowedMethods()
+	}
+
+	var routes, any []*route
+	var allow []string
+	for _, m := range pd.allowedMethods() {
+		for _, rt := range pd.methods[m] {
+			if strict && rt.slash != slash || !rt.passes(r) {
+				continue
+			}
+
+			if m == anyMethod {
+				any = append(any, rt)
+				continue
+			}
+
+			if m == r.Method {
+				routes = append(routes, rt)
+			}
+
+			if len(allow) == 0 || allow[len(allow)-1] != m {
+				allow = append(allow, m)
+			}
+		}
+	}
+
+	if routes == nil {
+		routes = any
+	}
+
+	if routes != nil {
+		allow = nil
+	}
+
+	return routes, allow
+}
+
+// passes reports whether all predicates of the route pass for the request.
+func (rt *route) passes(r *http.Request) bool {
+	for _, p := range rt.predicates {
+		if !p(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// overlaps reports whether both routes can accept the same request.
+// Routes with predicates never overlap, as predicates cannot be compared.
+func (rt *route) overlaps(other *route) bool {
+	if len(rt.predicates) > 0 || len(other.predicates) > 0 {
+		return false
+	}
+
+	// Routes without content type constraint accept the same requests.
+	if len(rt.contentTypes) == 0 || len(other.contentTypes) == 0 {
+		return len(rt.contentTypes) == len(other.contentTypes)
+	}
+
+	// Check if routes share a content type.
+	for _, t := range rt.contentTypes {
+		if rt.acceptsContentType(t) && other.acceptsContentType(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// acceptsContentType reports whether route restricts content type to t.
+func (rt *route) acceptsContentType(t string) bool {
+	for _, v := range rt.contentTypes {
+		if v == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectRoute returns the route that should handle the request. A route with
+// matching content type constraint wins over a route without constraint,
+// then a route with predicates wins over a route without them. Returns nil
+// if no route accepts the request.
+func selectRoute(rs []*route, r *http.Request) *route {
+	ct := mediaType(r.Header.Get("Content-Type"))
+
+	var best *route
+	bestScore := -1
+	for _, rt := range rs {
+		score := 0
+		if len(rt.contentTypes) > 0 {
+			// Skip route that does not accept the content type.
+			if !rt.acceptsContentType(ct) {
+				continue
+			}
+
+			score += 2
+		}
+
+		if len(rt.predicates) > 0 {
+			score++
+		}
+
+		if score > bestScore {
+			best, bestScore = rt, score
+		}
+	}
+
+	return best
+}
+
+// mediaType returns lowercase media type without parameters.
+func mediaType(ct string) string {
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(ct))
+}
+
+// containsString reports whether the slice contains the string.
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// equalStrings reports whether slices have the same elements.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasTrailingSlash reports whether the path other than the root path ends
+// with a slash.
+func hasTrailingSlash(p string) bool {
+	return len(p) > 1 && p[len(p)-1] == '/'
+}
+
+func normalizePath(p string) string {
+	// Return root path if empty string is received.
+	if len(p) == 0 {
+		return "/"
+	}
+
+	// Trim slashes at the end, replace backslashes and remove duplicate
+	// slashes.
+	s := cleanSlashes(strings.TrimRight(p, "/"))
+
+	// Convert the string to lower.
+	s = strings.ToLower(s)
+
+	// Add leading slash if needed.
+	if p[0] != '/' {
+		s = "/" + s
+	}
+
+	// Keep the slash of the root path.
+	if s == "" {
+		return "/"
+	}
+
+	// Return normalized path.
+	return s
+}
+
+// cleanSlashes replaces backslashes with slashes and removes duplicate
+// slashes.
+func cleanSlashes(s string) string {
+	// Replace backslashes with slashes (\ -> /).
+	s = strings.Replace(s, "\\", "/", -1)
+
+	// Remove duplicate slashes (// -> /).
+	for strings.Contains(s, "//") {
+		s = strings.Replace(s, "//", "/", -1)
+	}
+
+	return s
+}
+
+// parsePattern parses the pattern with the parameter marker set by
+// ParamPrefix.
+func (r *Router) parsePattern(pattern string) (*pathData, error) {
+	open, close := r.paramMarkers()
+	return parsePatternWith(pattern, open, close)
+}
+
+// paramMarkers returns the markers that start and end named parameters
+// in patterns, as set by ParamPrefix. The closing marker may be empty.
+func (r *Router) paramMarkers() (string, string) {
+	switch r.ParamPrefix {
+	case "", ":":
+		return ":", ""
+	case "{":
+		return "{", "}"
+	default:
+		return r.ParamPrefix, ""
+	}
+}
+
+// parsePatternWith parses the pattern with named parameters that start with
+// open and end with close, if it is not empty.
+func parsePatternWith(pattern string, open string, close string) (*pathData, error) {
+	// Extract regular expressions of parameters, so that normalization does
+	// not change them.
+	pattern, exprs, err := extractExprs(pattern, open)
+	if err != nil {
+		return nil, err
+	}
+
+	// Normalize pattern.
+	path := normalizePath(pattern)
+
+	pd := &pathData{methods: pathMethods{}}
+
+	// Split pattern into segments.
+	segs := splitPath(path)
+	for i, v := range segs {
+		seg := segment{value: v}
+
+		// Check if segment is a catch-all parameter.
+		if strings.HasPrefix(v, "*") {
+			seg = segment{value: v[1:], param: true, catchAll: true}
+
+			// Check position and name of the parameter.
+			if i != len(segs)-1 {
+				return nil, ErrCatchAll
+			}
+
+			if seg.value == "" || strings.ContainsAny(seg.value, wrongParamNameChars) {
+				return nil, ErrParameterName
+			}
+
+			pd.params = append(pd.params, seg.value)
+			v = "*"
+		} else if strings.HasPrefix(v, open) {
+			// Remove the closing marker, a suffix may follow it.
+			v = v[len(open):]
+			if close != "" {
+				i := strings.Index(v, close)
+				if i < 0 {
+					return nil, ErrParameterName
+				}
+
+				v = v[:i] + v[i+len(close):]
+			}
+
+			seg, err = parseParam(v, exprs)
+			if err != nil {
+				return nil, err
+			}
+
+			pd.params = append(pd.params, seg.value)
+
+			// Parameter names are not part of the path, so that patterns
+			// different only in parameter names share the path data.
+			v = ":" + seg.constraint()
+		}
+
+		pd.segments = append(pd.segments, seg)
+		pd.path += "/" + v
+	}
+
+	// Root path has no segments.
+	if pd.path == "" {
+		pd.path = "/"
+	}
+
+	// Return path data.
+	return pd, nil
+}
+
+// A paramType is a built-in parameter type that can be used in patterns
+// instead of a regular expression.
+type paramType struct {
+	expr string
+	re   *regexp.Regexp
+}
+
+// newParamType returns parameter type with precompiled expression.
+func newParamType(expr string) paramType {
+	return paramType{expr: expr, re: regexp.MustCompile("^(?:" + expr + ")$")}
+}
+
+// Built-in parameter types. Paths are matched in lower case, so expressions
+// do not need upper case letters.
+var paramTypes = map[string]paramType{
+	"int":   newParamType(`-?[0-9]+`),
+	"float": newParamType(`-?[0-9]+(?:\.[0-9]+)?`),
+	"uuid":  newParamType(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`),
+	"slug":  newParamType(`[a-z0-9]+(?:-[a-z0-9]+)*`),
+}
+
+// parseParam parses named parameter segment without the leading colon.
+// The name may be followed by a placeholder of a regular expression or
+// a type name after a vertical bar, and a literal suffix that starts with
+// a dot.
+func parseParam(v string, exprs []string) (segment, error) {
+	seg := segment{value: v, param: true}
+
+	// Split name and constraints.
+	rest := ""
+	if i := strings.IndexAny(v, "(.|"); i >= 0 {
+		seg.value, rest = v[:i], v[i:]
+	}
+
+	// Check parameter name.
+	if strings.ContainsAny(seg.value, wrongParamNameChars) {
+		return seg, ErrParameterName
+	}
+
+	// Use expression of the built-in type.
+	if strings.HasPrefix(rest, "|") {
+		name := rest[1:]
+		rest = ""
+		if i := strings.Index(name, "."); i >= 0 {
+			name, rest = name[:i], name[i:]
+		}
+
+		t, ok := paramTypes[name]
+		if !ok {
+			return seg, fmt.Errorf("%w: unknown parameter type %q", ErrParameterPattern, name)
+		}
+
+		seg.expr, seg.re = t.expr, t.re
+	}
+
+	// Compile regular expression.
+	if strings.HasPrefix(rest, "(") {
+		i := strings.Index(rest, ")")
+		n, _ := strconv.Atoi(rest[1:i])
+		seg.expr, rest = exprs[n], rest[i+1:]
+
+		re, err := regexp.Compile("^(?:" + seg.expr + ")$")
+		if err != nil {
+			return seg, fmt.Errorf("%w %#q: %v", ErrParameterPattern, seg.expr, err)
+		}
+
+		seg.re = re
+	}
+
+	// The rest is a literal suffix.
+	if rest != "" && !strings.HasPrefix(rest, ".") {
+		return seg, ErrParameterName
+	}
+
+	seg.suffix = rest
+
+	return seg, nil
+}
+
+// extractExprs replaces regular expressions of named parameters in the
+// pattern with numbered placeholders, like "(0)", and returns the pattern
+// with placeholders and the expressions. Named parameter segments start
+// with open.
+func extractExprs(pattern string, open string) (string, []string, error) {
+	var (
+		b      strings.Builder
+		exprs  []string
+		start  int
+		depth  int
+		escape bool
+	)
+
+	// Expressions are only allowed in named parameter segments.
+	param := strings.HasPrefix(pattern, open)
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+
+		// Copy characters outside of expressions.
+		if depth == 0 {
+			switch {
+			case c == '/' || c == '\\':
+				param = strings.HasPrefix(pattern[i+1:], open)
+			case c == '(' && param:
+				depth, start = 1, i+1
+				continue
+			}
+
+			b.WriteByte(c)
+			continue
+		}
+
+		// Find the end of expression, skipping escaped characters.
+		switch {
+		case escape:
+			escape = false
+		case c == '\\':
+			escape = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				b.WriteString("(" + strconv.Itoa(len(exprs)) + ")")
+				exprs = append(exprs, pattern[start:i])
+			}
+		}
+	}
+
+	// Check that all expressions are closed.
+	if depth != 0 {
+		return "", nil, ErrParameterPattern
+	}
+
+	return b.String(), exprs, nil
+}
+
+// segmentCount returns the number of non-empty segments of the path.
+func segmentCount(p string) int {
+	return len(splitPath(cleanSlashes(strings.TrimRight(p, "/"))))
+}
+
+// splitPath splits normalized path into segments.
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
+
+// getPathData returns the most preferred path data that matches the path
+// and is accepted by the accept function, which may be nil to accept any.
+// If count is not nil, it is increased by the number of comparisons made.
+func (t *routeTable) getPathData(path string, accept func(*pathData) bool, count *int) (*pathData, []string, string) {
+	// Normalize path.
+	path = normalizePath(path)
+
+	// Try to get route without named parameters.
+	if count != nil {
+		*count++
+	}
+
+	static, ok := t.routes[path]
+	if !ok || !static.static() || accept != nil && !accept(static) {
+		static = nil
+	}
+
+	// Static route wins unless there are dynamic routes with higher priority.
+	if static != nil && (len(t.dynamic) == 0 || t.dynamic[0].priority <= static.priority) {
+		// Return path data.
+		return static, nil, ""
+	}
+
+	// Try to get route with named parameters or mounted router.
+	segs := splitPath(path)
+	for _, pd := range t.dynamic {
+		// Dynamic route with lower priority than the static one never wins.
+		if static != nil && pd.priority <= static.priority {
+			break
+		}
+
+		if count != nil {
+			*count++
+		}
+
+		if values, rest, ok := pd.match(segs); ok && (accept == nil || accept(pd)) {
+			// Return path data, parameter values and the rest of the path.
+			return pd, values, rest
+		}
+	}
+
+	// Return static path data if found.
+	if static != nil {
+		return static, nil, ""
+	}
+
 	// Path data was not found.
-	return nil, ""
+	return nil, nil, ""
 }
@@ -0,0 +1,1740 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve sends the request to the router and returns the recorded response.
+// Headers are passed as name and value pairs.
+func serve(rt http.Handler, method string, path string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	return w
+}
+
+// reply returns a handler that writes the body.
+func reply(body string) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Write([]byte(body))
+	}
+}
+
+// echo writes the path of the request and the parameters.
+func echo(w http.ResponseWriter, r *http.Request, ps Params) {
+	fmt.Fprintf(w, "%s %v", r.URL.Path, map[string][]string(ps))
+}
+
+func TestCancelledContext(t *testing.T) {
+	rt := New()
+
+	called := false
+	rt.Get("/users/:id(\\d+)", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		called = true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest("GET", "/users/5", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	if called {
+		t.Error("handler called for cancelled request")
+	}
+
+	if w.Body.Len() != 0 || len(w.Header()) != 0 {
+		t.Errorf("response written for cancelled request: %v %q", w.Header(), w.Body.String())
+	}
+
+	// The same route serves requests that are not cancelled.
+	serve(rt, "GET", "/users/5")
+	if !called {
+		t.Error("handler not called")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	rt := New()
+	rt.Post("/users", reply("create"))
+	rt.Get("/users/:id", reply("user"))
+	rt.Get("/users", reply("list"))
+	rt.Delete("/users/:id", reply("delete"))
+
+	sub := New()
+	sub.Get("/status", reply("status"))
+	rt.Mount("/admin", sub)
+
+	want := "[GET /admin/status GET /users POST /users DELETE /users/:id GET /users/:id]"
+	for i := 0; i < 3; i++ {
+		var visited []string
+		err := rt.Walk(func(method string, pattern string, h HandlerFunc) error {
+			if h == nil {
+				t.Errorf("nil handler for %s %s", method, pattern)
+			}
+
+			visited = append(visited, method, pattern)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := fmt.Sprint(visited); got != want {
+			t.Fatalf("visited %s, want %s", got, want)
+		}
+	}
+
+	// Walk stops on the first error.
+	stop := errors.New("stop")
+	n := 0
+	err := rt.Walk(func(method string, pattern string, h HandlerFunc) error {
+		n++
+		if n == 2 {
+			return stop
+		}
+
+		return nil
+	})
+	if err != stop || n != 2 {
+		t.Errorf("got %v after %d routes, want %v after 2", err, n, stop)
+	}
+}
+
+func TestContentType(t *testing.T) {
+	rt := New()
+	if err := rt.HandleWith("POST", "/upload", reply("json"), WithContentType("application/json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rt.HandleWith("POST", "/upload", reply("form"), WithContentType("multipart/form-data")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rt.HandleWith("POST", "/upload", reply("other"), WithContentType("application/JSON")); err != ErrDuplicateHandler {
+		t.Errorf("got %v for duplicate content type, want %v", err, ErrDuplicateHandler)
+	}
+
+	tests := []struct {
+		contentType string
+		status      int
+		body        string
+	}{
+		{"application/json", http.StatusOK, "json"},
+		{"application/json; charset=utf-8", http.StatusOK, "json"},
+		{"multipart/form-data; boundary=x", http.StatusOK, "form"},
+		{"text/plain", http.StatusUnsupportedMediaType, ""},
+		{"", http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, "POST", "/upload", "Content-Type", tt.contentType)
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%q: got %d %q, want %d %q", tt.contentType, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+}
+
+func TestMountParams(t *testing.T) {
+	inner := New()
+	inner.Get("/users/:id", echo)
+	inner.Get("/", echo)
+	inner.Get("/t/:tenant", echo)
+
+	rt := New()
+	if err := rt.Mount("/tenants/:tenant", inner); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/tenants/acme/users/5", "/users/5 map[id:[5] tenant:[acme]]"},
+		{"/tenants/acme", "/ map[tenant:[acme]]"},
+
+		// Parameters of the inner pattern go before the outer ones, and form
+		// values go last.
+		{"/tenants/acme/t/inner?tenant=form", "/t/inner map[tenant:[inner acme form]]"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %q", tt.path, w.Code, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	// Bare router responds without body.
+	w := serve(New(), "GET", "/missing")
+	if w.Code != http.StatusNotFound || w.Body.Len() != 0 {
+		t.Errorf("bare: got %d %q", w.Code, w.Body.String())
+	}
+
+	// Static body is written with its content type.
+	rt := New()
+	rt.NotFoundBody = []byte(`{"error":"not found"}`)
+	rt.NotFoundContentType = "application/json"
+
+	w = serve(rt, "GET", "/missing")
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"error":"not found"}` || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("body: got %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+
+	// Handler wins over the body.
+	rt.NotFound = func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("handler"))
+	}
+
+	w = serve(rt, "GET", "/missing")
+	if w.Code != http.StatusNotFound || w.Body.String() != "handler" {
+		t.Errorf("handler: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestParamSuffix(t *testing.T) {
+	rt := New()
+	rt.Get("/reports/:id.json", echo)
+	rt.Get("/files/:name", echo)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/reports/7.json", "/reports/7.json map[id:[7]]"},
+		{"/files/archive.tar.gz", "/files/archive.tar.gz map[name:[archive.tar.gz]]"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %q", tt.path, w.Code, w.Body.String(), tt.body)
+		}
+	}
+
+	for _, path := range []string{"/reports/7.xml", "/reports/.json", "/reports/7"} {
+		if w := serve(rt, "GET", path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: got %d, want 404", path, w.Code)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	rt := New()
+	rt.Get("/items/:id", reply("get"))
+	rt.Post("/items/:id", reply("post"))
+
+	// Parameter names do not matter for removal.
+	if !rt.Remove("GET", "/items/:name") {
+		t.Fatal("route not removed")
+	}
+
+	if rt.Remove("GET", "/items/:id") {
+		t.Error("removed route removed again")
+	}
+
+	// Other methods remain.
+	w := serve(rt, "GET", "/items/1")
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "POST" {
+		t.Errorf("got %d with Allow %q, want 405 with POST", w.Code, w.Header().Get("Allow"))
+	}
+
+	// Path is gone with its last method.
+	rt.Remove("POST", "/items/:id")
+	if w := serve(rt, "POST", "/items/1"); w.Code != http.StatusNotFound {
+		t.Errorf("got %d, want 404", w.Code)
+	}
+
+	// Priority of the removed route is not kept by the path.
+	rt.Get("/files/*path", reply("files"))
+	rt.HandleWith("POST", "/files/*path", reply("upload"), WithPriority(10))
+	rt.Get(`/files/:id(\d+)`, reply("id"))
+	if w := serve(rt, "GET", "/files/5"); w.Body.String() != "files" {
+		t.Errorf("got %q, want %q before removal", w.Body.String(), "files")
+	}
+
+	rt.Remove("POST", "/files/*path")
+	if w := serve(rt, "GET", "/files/5"); w.Body.String() != "id" {
+		t.Errorf("got %q, want %q after removal", w.Body.String(), "id")
+	}
+}
+
+func TestRootCatchAll(t *testing.T) {
+	rt := New()
+	rt.Get("/*path", echo)
+	rt.Get("/api/users", reply("users"))
+	rt.Get("/api/users/:id", reply("user"))
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/api/users", "users"},
+		{"/api/users/5", "user"},
+		{"/", "/ map[path:[]]"},
+		{"/a", "/a map[path:[a]]"},
+		{"/a/b/c", "/a/b/c map[path:[a/b/c]]"},
+		{"/api/users/5/posts", "/api/users/5/posts map[path:[api/users/5/posts]]"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %q", tt.path, w.Code, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestPreRoute(t *testing.T) {
+	rt := New()
+	rt.Get("/a", reply("a"))
+	rt.PreRoute = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("X-Scanner") != "" {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+
+		return true
+	}
+
+	if w := serve(rt, "GET", "/a", "X-Scanner", "1"); w.Code != http.StatusForbidden || w.Body.Len() != 0 {
+		t.Errorf("rejected: got %d %q, want 403", w.Code, w.Body.String())
+	}
+
+	if w := serve(rt, "GET", "/a"); w.Body.String() != "a" {
+		t.Errorf("accepted: got %d %q, want %q", w.Code, w.Body.String(), "a")
+	}
+
+	// Panics of the hook are recovered.
+	var recovered interface{}
+	rt.PanicHandler = func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		recovered = err
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	rt.PreRoute = func(w http.ResponseWriter, r *http.Request) bool {
+		panic("hook")
+	}
+
+	if w := serve(rt, "GET", "/a"); w.Code != http.StatusInternalServerError || recovered != "hook" {
+		t.Errorf("panic: got %d with %v, want 500 with %q", w.Code, recovered, "hook")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	rt := New()
+	if paths, handlers := rt.Summary(); paths != 0 || handlers != 0 {
+		t.Errorf("empty router: got %d paths and %d handlers", paths, handlers)
+	}
+
+	rt.Get("/a", reply(""))
+	rt.Post("/a", reply(""))
+	rt.Get("/b/:x", reply(""))
+	rt.Get("/b/:y/c", reply(""))
+
+	inner := New()
+	inner.Get("/z", reply(""))
+	rt.Mount("/m", inner)
+
+	if paths, handlers := rt.Summary(); paths != 4 || handlers != 5 {
+		t.Errorf("got %d paths and %d handlers, want 4 and 5", paths, handlers)
+	}
+}
+
+func TestParamConstraints(t *testing.T) {
+	rt := New()
+	if err := rt.Get(`/api/:version(v1|v2)/users/:id(\d+)`, echo); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pattern := range []string{`/x/:id(\d+`, `/x/:id([)`} {
+		if err := rt.Get(pattern, echo); !errors.Is(err, ErrParameterPattern) {
+			t.Errorf("%s: got %v, want %v", pattern, err, ErrParameterPattern)
+		}
+	}
+
+	if w := serve(rt, "GET", "/api/v1/users/5"); w.Body.String() != "/api/v1/users/5 map[id:[5] version:[v1]]" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{"/api/v3/users/5", "/api/v1/users/bob"} {
+		if w := serve(rt, "GET", path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: got %d, want 404", path, w.Code)
+		}
+	}
+
+	// Unconstrained route gets paths rejected by the constraints.
+	rt.Get("/api/:version/users/:id", reply("loose"))
+	if w := serve(rt, "GET", "/api/v3/users/5"); w.Body.String() != "loose" {
+		t.Errorf("got %d %q, want %q", w.Code, w.Body.String(), "loose")
+	}
+}
+
+func TestHandleIf(t *testing.T) {
+	enabled := false
+	flag := func(*http.Request) bool { return enabled }
+
+	rt := New()
+	rt.HandleIf(flag, "GET", "/search", reply("new"))
+	rt.Get("/search", reply("old"))
+	rt.HandleIf(flag, "POST", "/items", reply("create"))
+	rt.Get("/items", reply("list"))
+
+	tests := []struct {
+		enabled bool
+		method  string
+		path    string
+		status  int
+		body    string
+		allow   string
+	}{
+		{false, "GET", "/search", 200, "old", ""},
+		{false, "POST", "/items", 405, "", "GET"},
+		{true, "GET", "/search", 200, "new", ""},
+		{true, "POST", "/items", 200, "create", ""},
+		{true, "PUT", "/items", 405, "", "GET, POST"},
+	}
+
+	for _, tt := range tests {
+		enabled = tt.enabled
+		w := serve(rt, tt.method, tt.path)
+		if w.Code != tt.status || w.Body.String() != tt.body || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%v %s %s: got %d %q with Allow %q, want %d %q with %q", tt.enabled, tt.method, tt.path,
+				w.Code, w.Body.String(), w.Header().Get("Allow"), tt.status, tt.body, tt.allow)
+		}
+	}
+}
+
+func TestParamNames(t *testing.T) {
+	var names []string
+	rt := New()
+	rt.Get("/users/:user/posts/:post/*rest", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		names = ParamNames(r)
+	})
+
+	serve(rt, "GET", "/users/1/posts/2/a/b")
+
+	if got, want := fmt.Sprint(names), "[user post rest]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMaxRequestBody(t *testing.T) {
+	rt := New()
+	rt.MaxRequestBody = 10
+	rt.Post("/form", reply("ok"))
+
+	for body, status := range map[string]int{"a=1": 200, "a=" + strings.Repeat("x", 100): 413} {
+		req := httptest.NewRequest("POST", "/form", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+
+		if w.Code != status {
+			t.Errorf("%d bytes: got %d, want %d", len(body), w.Code, status)
+		}
+	}
+}
+
+func TestRedirectCanonical(t *testing.T) {
+	rt := New()
+	rt.RedirectCanonical = true
+	rt.Get("/a/b", reply("ok"))
+	rt.Post("/a/b", reply("post"))
+
+	tests := []struct {
+		path     string
+		query    string
+		location string
+	}{
+		{`/a\b`, "x=1", "/a/b?x=1"},
+		{`/\a/b`, "", "/a/b"},
+		{"//a//b", "", "/a/b"},
+	}
+
+	for _, tt := range tests {
+		// Paths are set directly, as httptest does not accept all of them.
+		req := httptest.NewRequest("GET", "/", nil)
+		req.URL.Path, req.URL.RawQuery = tt.path, tt.query
+
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s: got %d to %q, want 301 to %q", tt.path, w.Code, w.Header().Get("Location"), tt.location)
+		}
+	}
+
+	if w := serve(rt, "GET", "/a/b"); w.Code != http.StatusOK {
+		t.Errorf("canonical path: got %d, want 200", w.Code)
+	}
+
+	// Only GET and HEAD requests are redirected.
+	req := httptest.NewRequest("POST", "/", nil)
+	req.URL.Path = "//a//b"
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	if w.Body.String() != "post" {
+		t.Errorf("POST: got %d %q, want %q", w.Code, w.Body.String(), "post")
+	}
+}
+
+func TestPriority(t *testing.T) {
+	rt := New()
+	rt.Get(`/files/:id(\d+)`, reply("id"))
+	rt.Get("/files/*path", reply("path"))
+	if w := serve(rt, "GET", "/files/5"); w.Body.String() != "id" {
+		t.Errorf("default: got %q, want %q", w.Body.String(), "id")
+	}
+
+	// Higher priority flips the winner.
+	rt = New()
+	rt.Get(`/files/:id(\d+)`, reply("id"))
+	rt.HandleWith("GET", "/files/*path", reply("path"), WithPriority(10))
+	if w := serve(rt, "GET", "/files/5"); w.Body.String() != "path" {
+		t.Errorf("priority: got %q, want %q", w.Body.String(), "path")
+	}
+}
+
+func TestUnknown501(t *testing.T) {
+	rt := New()
+	rt.Get("/a", reply("a"))
+
+	if w := serve(rt, "FOO", "/a"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("default: got %d, want 405", w.Code)
+	}
+
+	rt.Unknown501 = true
+	for _, path := range []string{"/a", "/missing"} {
+		if w := serve(rt, "FOO", path); w.Code != http.StatusNotImplemented {
+			t.Errorf("%s: got %d, want 501", path, w.Code)
+		}
+	}
+
+	// Known methods are not affected.
+	if w := serve(rt, "POST", "/a"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: got %d, want 405", w.Code)
+	}
+}
+
+func TestAnyMethod(t *testing.T) {
+	rt := New()
+	if err := rt.Handle("GTE", "/a", reply("a")); err != ErrMethod {
+		t.Errorf("got %v, want %v", err, ErrMethod)
+	}
+
+	rt.Handle("", "/a", reply("any"))
+	rt.Get("/a", reply("get"))
+
+	for method, body := range map[string]string{"GET": "get", "POST": "any", "DELETE": "any"} {
+		if w := serve(rt, method, "/a"); w.Body.String() != body {
+			t.Errorf("%s: got %q, want %q", method, w.Body.String(), body)
+		}
+	}
+}
+
+func TestMaxSegments(t *testing.T) {
+	rt := New()
+	rt.Get("/*path", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Write([]byte("ok"))
+	})
+	rt.MaxSegments = 2
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/", http.StatusOK},
+		{"/a/b", http.StatusOK},
+		{"/a/b/", http.StatusOK},
+		{"/a//b", http.StatusOK},
+		{"/a/b/c", http.StatusRequestURITooLong},
+		{"/a/b/c/d/e/f", http.StatusRequestURITooLong},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.path, w.Code, tt.status)
+		}
+	}
+}
+
+func TestParamTypes(t *testing.T) {
+	rt := New()
+	rt.Get("/int/:v|int", reply("int"))
+	rt.Get("/float/:v|float", reply("float"))
+	rt.Get("/uuid/:v|uuid", reply("uuid"))
+	rt.Get("/slug/:v|slug", reply("slug"))
+
+	if err := rt.Get("/x/:v|bogus", reply("")); !errors.Is(err, ErrParameterPattern) {
+		t.Errorf("got %v for unknown type, want %v", err, ErrParameterPattern)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/int/42", 200},
+		{"/int/-12", 200},
+		{"/int/1a", 404},
+		{"/float/1.5", 200},
+		{"/float/2", 200},
+		{"/float/x", 404},
+		{"/uuid/123e4567-e89b-12d3-a456-426614174000", 200},
+		{"/uuid/123", 404},
+		{"/slug/hello-world", 200},
+		{"/slug/-bad", 404},
+		{"/slug/a--b", 404},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Code != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.path, w.Code, tt.status)
+		}
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	// By default the trailing slash is ignored.
+	rt := New()
+	rt.Get("/users", reply("users"))
+	for _, path := range []string{"/users", "/users/"} {
+		if w := serve(rt, "GET", path); w.Body.String() != "users" {
+			t.Errorf("default %s: got %d %q", path, w.Code, w.Body.String())
+		}
+	}
+
+	// With StrictSlash the slash must match.
+	rt = New()
+	rt.StrictSlash = true
+	rt.Get("/users", reply("users"))
+	rt.Get("/items/", reply("items/"))
+
+	if w := serve(rt, "GET", "/items"); w.Code != http.StatusNotFound {
+		t.Errorf("strict /items: got %d, want 404", w.Code)
+	}
+
+	// With LenientSlash both forms reach the only route.
+	rt.LenientSlash = true
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/users", "users"},
+		{"/users/", "users"},
+		{"/items", "items/"},
+		{"/items/", "items/"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.body {
+			t.Errorf("lenient %s: got %d %q, want %q", tt.path, w.Code, w.Body.String(), tt.body)
+		}
+	}
+
+	// The exact form wins if both are registered.
+	rt.Get("/users/", reply("users/"))
+	if w := serve(rt, "GET", "/users/"); w.Body.String() != "users/" {
+		t.Errorf("exact /users/: got %q", w.Body.String())
+	}
+}
+
+func TestPanicCancelled(t *testing.T) {
+	handled, logged := false, false
+
+	rt := New()
+	rt.PanicHandler = func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		handled = true
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	rt.PanicLogger = func(r *http.Request, err interface{}) {
+		logged = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rt.Get("/panic", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		cancel()
+		panic("gone")
+	})
+
+	// The client is gone, so the panic is only logged.
+	req := httptest.NewRequest("GET", "/panic", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	if handled || !logged || w.Body.Len() != 0 || len(w.Header()) != 0 {
+		t.Errorf("got handled %v, logged %v, response %v %q", handled, logged, w.Header(), w.Body.String())
+	}
+
+	// Panics of other requests are handled.
+	if w := serve(rt, "GET", "/panic"); !handled || w.Code != http.StatusInternalServerError {
+		t.Errorf("got handled %v with %d, want 500", handled, w.Code)
+	}
+}
+
+func TestOnTiming(t *testing.T) {
+	var pattern string
+	var duration time.Duration
+
+	rt := New()
+	rt.OnTiming = func(p string, d time.Duration) {
+		pattern, duration = p, d
+	}
+
+	rt.Get("/users/:id", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		time.Sleep(time.Millisecond)
+	})
+
+	rt.Get("/panic/:id", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		time.Sleep(time.Millisecond)
+		panic("handler")
+	})
+
+	for path, want := range map[string]string{"/users/1": "/users/:id", "/panic/1": "/panic/:id"} {
+		pattern, duration = "", 0
+		serve(rt, "GET", path)
+
+		if pattern != want || duration < time.Millisecond {
+			t.Errorf("%s: got %q in %v, want %q in at least 1ms", path, pattern, duration, want)
+		}
+	}
+}
+
+func TestParamNameCollision(t *testing.T) {
+	rt := New()
+	rt.Get("/users/:id", reply(""))
+
+	err := rt.Get("/users/:name", reply(""))
+	want := "router: handler for this path and method combination was already registered: " +
+		"pattern differs from `/users/:id` only in parameter names, which are not used for matching"
+	if !errors.Is(err, ErrDuplicateHandler) || err.Error() != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+
+	// The same pattern gets the plain error.
+	if err := rt.Get("/users/:id", reply("")); err != ErrDuplicateHandler {
+		t.Errorf("got %v, want %v", err, ErrDuplicateHandler)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	self := func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Write([]byte(Template(r)))
+	}
+
+	rt, shop := New(), New()
+	rt.Get("/Users/:ID|int", self)
+	shop.Get("/Items/:name", self)
+	rt.Mount("/Shop/:Tenant", shop)
+
+	// Templates are not normalized.
+	for path, want := range map[string]string{"/users/5": "/Users/:ID|int", "/shop/a/items/b": "/Shop/:Tenant/Items/:name"} {
+		if w := serve(rt, "GET", path); w.Body.String() != want {
+			t.Errorf("%s: got %q, want %q", path, w.Body.String(), want)
+		}
+	}
+}
+
+func TestParamSource(t *testing.T) {
+	rt := New()
+	rt.Post("/form", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Write([]byte(strings.Join(ps["v"], ",")))
+	})
+
+	tests := []struct {
+		source ParamSource
+		values string
+	}{
+		// Body values go first.
+		{ParamSourceBoth, "body,query"},
+		{ParamSourceQuery, "query"},
+		{ParamSourceBody, "body"},
+	}
+
+	for _, tt := range tests {
+		rt.ParamSource = tt.source
+
+		req := httptest.NewRequest("POST", "/form?v=query", strings.NewReader("v=body"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+
+		if w.Body.String() != tt.values {
+			t.Errorf("source %d: got %q, want %q", tt.source, w.Body.String(), tt.values)
+		}
+	}
+}
+
+func TestServerOptions(t *testing.T) {
+	rt, sub := New(), New()
+	rt.Get("/a", reply(""))
+	rt.Post("/b/:id", reply(""))
+	sub.Delete("/x", reply(""))
+	rt.Mount("/m", sub)
+	rt.Host("example.com").Put("/", reply(""))
+
+	srv := httptest.NewUnstartedServer(rt)
+	srv.Config.DisableGeneralOptionsHandler = true
+	srv.Start()
+	defer srv.Close()
+
+	// Clients do not send "*" as the request target, so the request is
+	// written by hand.
+	options := func() *http.Response {
+		t.Helper()
+
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "OPTIONS * HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+
+		res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		return res
+	}
+
+	if res := options(); res.StatusCode != http.StatusOK || res.Header.Get("Allow") != "DELETE, GET, OPTIONS, POST, PUT" {
+		t.Errorf("got %d with Allow %q", res.StatusCode, res.Header.Get("Allow"))
+	}
+
+	rt.ServerOptions = func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	if res := options(); res.StatusCode != http.StatusNoContent {
+		t.Errorf("got %d from ServerOptions, want 204", res.StatusCode)
+	}
+}
+
+func TestRouteMeta(t *testing.T) {
+	rt, sub := New(), New()
+	rt.HandleWith("GET", "/users", reply(""),
+		WithMeta(map[string]interface{}{"summary": "List users"}),
+		WithMeta(map[string]interface{}{"tags": "users"}))
+	sub.HandleWith("POST", "/items", reply(""), WithMeta(map[string]interface{}{"scope": "write"}))
+	rt.Mount("/admin", sub)
+	rt.Get("/plain", reply(""))
+
+	want := map[string]map[string]interface{}{
+		"/admin/items": {"scope": "write"},
+		"/plain":       nil,
+		"/users":       {"summary": "List users", "tags": "users"},
+	}
+
+	routes := rt.Routes()
+	if len(routes) != len(want) {
+		t.Fatalf("got %d routes, want %d", len(routes), len(want))
+	}
+
+	for _, route := range routes {
+		if meta := want[route.Pattern]; !reflect.DeepEqual(route.Meta, meta) {
+			t.Errorf("%s: got %v, want %v", route.Pattern, route.Meta, meta)
+		}
+	}
+}
+
+func TestRedirectLowercase(t *testing.T) {
+	name := func(w http.ResponseWriter, r *http.Request, ps Params) {
+		v, _ := ps.Get("name")
+		w.Write([]byte(v))
+	}
+
+	rt := New()
+	rt.Get("/users/:name", name)
+
+	// Parameter values are lowercase by default.
+	if w := serve(rt, "GET", "/Users/Bob"); w.Body.String() != "bob" {
+		t.Errorf("got %q, want %q", w.Body.String(), "bob")
+	}
+
+	rt.RedirectLowercase = true
+	tests := []struct {
+		preserve bool
+		path     string
+		status   int
+		location string
+	}{
+		{false, "/USERS/bob?x=1", 301, "/users/bob?x=1"},
+		{false, "/users/Bob", 301, "/users/bob"},
+		{false, "/users/bob", 200, ""},
+
+		// Parameter values keep their case, so only static segments are
+		// redirected.
+		{true, "/USERS/Bob", 301, "/users/Bob"},
+		{true, "/users/Bob", 200, ""},
+	}
+
+	for _, tt := range tests {
+		rt.PreserveParamCase = tt.preserve
+		w := serve(rt, "GET", tt.path)
+		if w.Code != tt.status || w.Header().Get("Location") != tt.location {
+			t.Errorf("%v %s: got %d to %q, want %d to %q", tt.preserve, tt.path, w.Code, w.Header().Get("Location"),
+				tt.status, tt.location)
+		}
+	}
+
+	if w := serve(rt, "GET", "/users/Bob"); w.Body.String() != "Bob" {
+		t.Errorf("got %q, want %q", w.Body.String(), "Bob")
+	}
+}
+
+func TestStaticRoutes(t *testing.T) {
+	rt, sub, tenant := New(), New(), New()
+	rt.Get("/", reply(""))
+	rt.Get("/About", reply(""))
+	rt.Handle("", "/any", reply(""))
+	rt.Post("/post", reply(""))
+	rt.Get("/users/:id", reply(""))
+	rt.Get("/files/*path", reply(""))
+	sub.Get("/x", reply(""))
+	tenant.Get("/y", reply(""))
+	rt.Mount("/docs", sub)
+	rt.Mount("/t/:id", tenant)
+
+	want := []string{"/", "/about", "/any", "/docs/x"}
+	if got := rt.StaticRoutes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Paths of mounted routers are listed once.
+	rt, inner := New(), New()
+	rt.Get("/m/a", reply(""))
+	rt.Get("/m/z", reply(""))
+	inner.Get("/a", reply(""))
+	inner.Get("/z", reply(""))
+	rt.Mount("/m", inner)
+
+	want = []string{"/m/a", "/m/z"}
+	if got := rt.StaticRoutes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParamPrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		pattern string
+	}{
+		{"{", "/users/{id}/posts/{post}"},
+		{"$", "/users/$id/posts/$post"},
+		{":", "/users/:id/posts/:post"},
+	}
+
+	for _, tt := range tests {
+		rt := New()
+		rt.ParamPrefix = tt.prefix
+		if err := rt.Get(tt.pattern, echo); err != nil {
+			t.Errorf("%s: %v", tt.pattern, err)
+			continue
+		}
+
+		if w := serve(rt, "GET", "/users/1/posts/2"); w.Body.String() != "/users/1/posts/2 map[id:[1] post:[2]]" {
+			t.Errorf("%s: got %d %q", tt.pattern, w.Code, w.Body.String())
+		}
+	}
+
+	// Other markers are literal.
+	rt := New()
+	rt.ParamPrefix = "{"
+	rt.Get("/users/:id", reply("literal"))
+	if w := serve(rt, "GET", "/users/:id"); w.Body.String() != "literal" {
+		t.Errorf("got %d %q, want %q", w.Code, w.Body.String(), "literal")
+	}
+}
+
+func TestHandlePrefix(t *testing.T) {
+	rt := New()
+	err := rt.HandlePrefix("GET", "/legacy", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		rest, ok := ps.Get(RestParam)
+		if !ok {
+			t.Errorf("%s: no rest parameter", r.URL.Path)
+		}
+
+		w.Write([]byte("rest=" + rest))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt.Get("/legacy/new", reply("new"))
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/legacy", "rest="},
+		{"/legacy/", "rest="},
+		{"/legacy/old/page", "rest=/old/page"},
+
+		// Exact routes win.
+		{"/legacy/new", "new"},
+		{"/legacy/new/page", "rest=/new/page"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %q", tt.path, w.Code, w.Body.String(), tt.body)
+		}
+	}
+
+	if w := serve(rt, "GET", "/legacyx"); w.Code != http.StatusNotFound {
+		t.Errorf("got %d, want 404", w.Code)
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	rt := New()
+	rt.DefaultHeaders = http.Header{
+		"X-Content-Type-Options": {"nosniff"},
+		"X-Frame-Options":        {"DENY"},
+	}
+	rt.Get("/page", reply("page"))
+	rt.Get("/embed", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		frame  string
+	}{
+		{"GET", "/page", http.StatusOK, "DENY"},
+		{"GET", "/missing", http.StatusNotFound, "DENY"},
+		{"POST", "/page", http.StatusMethodNotAllowed, "DENY"},
+
+		// Handlers override default headers.
+		{"GET", "/embed", http.StatusOK, "SAMEORIGIN"},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, tt.method, tt.path)
+		if w.Code != tt.status || w.Header().Get("X-Frame-Options") != tt.frame {
+			t.Errorf("%s %s: got %d with X-Frame-Options %q, want %d with %q",
+				tt.method, tt.path, w.Code, w.Header().Get("X-Frame-Options"), tt.status, tt.frame)
+		}
+
+		if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("%s %s: got headers %v, want X-Content-Type-Options", tt.method, tt.path, w.Header())
+		}
+	}
+}
+
+func TestRawBody(t *testing.T) {
+	const body = "v=body&upload=" + "0123456789"
+
+	rt := New()
+	rt.HandleWith("POST", "/upload", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		// Only query values are added to Params.
+		w.Write([]byte(strings.Join(ps["v"], ",") + " " + string(b)))
+	}, WithRawBody())
+
+	req := httptest.NewRequest("POST", "/upload?v=query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	if want := "query " + body; w.Body.String() != want {
+		t.Errorf("got %q, want %q", w.Body.String(), want)
+	}
+}
+
+func TestParamCatchAll(t *testing.T) {
+	rt := New()
+	rt.Get("/a/:first/*rest", echo)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/a/x/y/z", "/a/x/y/z map[first:[x] rest:[y/z]]"},
+		{"/a/x/", "/a/x/ map[first:[x] rest:[]]"},
+		{"/a/x", "/a/x map[first:[x] rest:[]]"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %q", tt.path, w.Code, w.Body.String(), tt.body)
+		}
+	}
+
+	if w := serve(rt, "GET", "/a"); w.Code != http.StatusNotFound {
+		t.Errorf("got %d, want 404", w.Code)
+	}
+}
+
+func TestRejectInvalidUTF8(t *testing.T) {
+	rt := New()
+	rt.Get("/:name", reply("ok"))
+
+	tests := []struct {
+		path   string
+		reject bool
+		status int
+	}{
+		{"/%ff%fe", false, http.StatusOK},
+		{"/%ff%fe", true, http.StatusBadRequest},
+		{"/caf%C3%A9", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rt.RejectInvalidUTF8 = tt.reject
+		if w := serve(rt, "GET", tt.path); w.Code != tt.status {
+			t.Errorf("%s with reject %v: got %d, want %d", tt.path, tt.reject, w.Code, tt.status)
+		}
+	}
+}
+
+func TestWithExtensions(t *testing.T) {
+	rt := New()
+	rt.HandleWith("GET", "/images/*path", reply("image"), WithExtensions(".jpg", ".PNG", ".webp"))
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/images/a/b.jpg", http.StatusOK},
+		{"/images/b.png", http.StatusOK},
+		{"/images/B.JPG", http.StatusOK},
+		{"/images/notes.txt", http.StatusNotFound},
+		{"/images/b", http.StatusNotFound},
+		{"/images", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Code != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.path, w.Code, tt.status)
+		}
+	}
+}
+
+func TestContextValues(t *testing.T) {
+	// lookup reads the context values like code without access to the
+	// request would.
+	lookup := func(ctx context.Context) string {
+		ps, ok := ParamsFromContext(ctx)
+		return fmt.Sprintf("%s %v %v", PatternFromContext(ctx), map[string][]string(ps), ok)
+	}
+
+	rt, sub := New(), New()
+	rt.ParamsInContext = true
+	sub.ParamsInContext = true
+	rt.Get("/users/:id", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Write([]byte(lookup(r.Context())))
+	})
+	rt.Mount("/teams/:team", sub)
+	sub.Get("/members", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Write([]byte(lookup(r.Context())))
+	})
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/users/7", "/users/:id map[id:[7]] true"},
+		{"/teams/go/members", "/teams/:team/members map[team:[go]] true"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Body.String() != tt.body {
+			t.Errorf("%s: got %q, want %q", tt.path, w.Body.String(), tt.body)
+		}
+	}
+
+	if got := lookup(context.Background()); got != " map[] false" {
+		t.Errorf("got %q for empty context", got)
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	rt := New()
+	rt.Timeouts = map[string]time.Duration{"GET": time.Second, "POST": time.Minute}
+	rt.Handle("", "/report", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		if d, ok := r.Context().Deadline(); ok {
+			fmt.Fprint(w, time.Until(d).Round(time.Second))
+		}
+	})
+
+	tests := []struct {
+		method  string
+		timeout string
+	}{
+		{"GET", "1s"},
+		{"POST", "1m0s"},
+		{"PUT", ""},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, tt.method, "/report"); w.Body.String() != tt.timeout {
+			t.Errorf("%s: got deadline in %q, want %q", tt.method, w.Body.String(), tt.timeout)
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	rt, static := New(), New()
+	rt.Get("/", reply(""))
+	rt.Handle("", "/any", reply(""))
+	rt.Get("/api/users", reply(""))
+	rt.Post("/api/users", reply(""))
+	rt.Get("/api/users/:id", reply(""))
+	rt.Delete("/api/users/:id", reply(""))
+	rt.Mount("/static", static)
+	static.Get("/*path", reply(""))
+
+	tree := rt.Tree()
+
+	for _, line := range []string{
+		"/ [GET]\n",
+		"\n  any [ANY]\n",
+		"\n  api\n    users [GET POST]\n      :id [DELETE GET]\n",
+		"\n  static (mounted)\n    *path [GET]\n",
+	} {
+		if !strings.Contains(tree, line) {
+			t.Errorf("got tree\n%s\nwant it to contain\n%s", tree, line)
+		}
+	}
+
+	// Routes of a mounted router are merged with the routes that share
+	// the mount pattern.
+	rt, inner := New(), New()
+	rt.Get("/m/a", reply(""))
+	rt.Get("/m/b", reply(""))
+	inner.Get("/a", reply(""))
+	inner.Post("/a", reply(""))
+	inner.Get("/z", reply(""))
+	rt.Mount("/m", inner)
+
+	want := "/\n  m (mounted)\n    a [GET POST]\n    b [GET]\n    z [GET]\n"
+	if tree := rt.Tree(); tree != want {
+		t.Errorf("got tree\n%s\nwant\n%s", tree, want)
+	}
+}
+
+func TestBefore(t *testing.T) {
+	var seen []string
+
+	rt := New()
+	rt.Get("/a", reply("a"))
+	rt.Before(func(w http.ResponseWriter, r *http.Request) bool {
+		seen = append(seen, "log "+r.URL.Path)
+		return true
+	})
+	rt.Before(func(w http.ResponseWriter, r *http.Request) bool {
+		seen = append(seen, "check "+r.URL.Path)
+		if r.URL.Path == "/blocked" {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+
+		return true
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/a", http.StatusOK},
+		{"GET", "/missing", http.StatusNotFound},
+		{"POST", "/a", http.StatusMethodNotAllowed},
+		{"GET", "/blocked", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, tt.method, tt.path); w.Code != tt.status {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, w.Code, tt.status)
+		}
+	}
+
+	want := []string{
+		"log /a", "check /a",
+		"log /missing", "check /missing",
+		"log /a", "check /a",
+		"log /blocked", "check /blocked",
+	}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("got hooks %q, want %q", seen, want)
+	}
+}
+
+func TestHide405(t *testing.T) {
+	rt := New()
+	rt.NotFoundBody = []byte("not found")
+	rt.Get("/a", reply("a"))
+	rt.Hide405 = true
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/a", http.StatusOK, "a"},
+
+		// Wrong methods look like missing paths.
+		{"POST", "/a", http.StatusNotFound, "not found"},
+		{"POST", "/b", http.StatusNotFound, "not found"},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, tt.method, tt.path)
+		if w.Code != tt.status || w.Body.String() != tt.body || w.Header().Get("Allow") != "" {
+			t.Errorf("%s %s: got %d %q with headers %v, want %d %q without Allow",
+				tt.method, tt.path, w.Code, w.Body.String(), w.Header(), tt.status, tt.body)
+		}
+	}
+}
+
+func TestParamFromHeader(t *testing.T) {
+	rt := New()
+	rt.ParamFromHeader("tenant", "X-Tenant")
+	rt.Get("/t/:tenant/users", echo)
+	rt.Get("/users", echo)
+
+	tests := []struct {
+		path   string
+		header string
+		body   string
+	}{
+		// The path wins.
+		{"/t/acme/users", "other", "/t/acme/users map[tenant:[acme]]"},
+
+		// The header goes before form values.
+		{"/users", "acme", "/users map[tenant:[acme]]"},
+		{"/users?tenant=form", "acme", "/users map[tenant:[acme form]]"},
+		{"/users", "", "/users map[]"},
+	}
+
+	for _, tt := range tests {
+		var headers []string
+		if tt.header != "" {
+			headers = []string{"X-Tenant", tt.header}
+		}
+
+		if w := serve(rt, "GET", tt.path, headers...); w.Body.String() != tt.body {
+			t.Errorf("%s with %q: got %q, want %q", tt.path, tt.header, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	rt, sub := New(), New()
+	rt.Get("/a", reply(""))
+	rt.Post("/a", reply(""))
+	rt.Get("/users/:id", reply(""))
+	rt.Delete("/users/:id", reply(""))
+	rt.Mount("/sub", sub)
+	sub.Put("/b", reply(""))
+
+	tests := []struct {
+		path    string
+		methods []string
+	}{
+		{"/a", []string{"GET", "POST"}},
+		{"/users/7", []string{"DELETE", "GET"}},
+		{"/sub/b", []string{"PUT"}},
+		{"/missing", nil},
+		{"/sub/missing", nil},
+	}
+
+	for _, tt := range tests {
+		if got := rt.AllowedMethods(tt.path); !reflect.DeepEqual(got, tt.methods) {
+			t.Errorf("%s: got %#v, want %#v", tt.path, got, tt.methods)
+		}
+	}
+}
+
+// recordingWriter records the status code and the body written through it.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   strings.Builder
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestWrapWriter(t *testing.T) {
+	var rw *recordingWriter
+
+	rt := New()
+	rt.NotFoundBody = []byte("not found")
+	rt.WrapWriter = func(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+		rw = &recordingWriter{ResponseWriter: w}
+		return rw
+	}
+	rt.Get("/page", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.Write([]byte("hello, "))
+		fmt.Fprint(w, "world")
+	})
+	rt.Get("/panic", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		panic("handler")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/page", 0, "hello, world"},
+		{"/panic", http.StatusInternalServerError, ""},
+		{"/missing", http.StatusNotFound, "not found"},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, "GET", tt.path)
+		if rw.status != tt.status || rw.body.String() != tt.body || w.Body.String() != tt.body {
+			t.Errorf("%s: recorded %d %q, want %d %q", tt.path, rw.status, rw.body.String(), tt.status, tt.body)
+		}
+	}
+}
+
+func TestWithPanicHandler(t *testing.T) {
+	var logged []string
+
+	rt := New()
+	rt.Timeouts = map[string]time.Duration{"GET": 10 * time.Millisecond}
+	rt.PanicHandler = func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "router ", err)
+	}
+	rt.PanicLogger = func(r *http.Request, err interface{}) {
+		logged = append(logged, r.URL.Path)
+	}
+
+	page := WithPanicHandler(func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, "route ", err)
+	})
+
+	// late panics after the deadline of the request.
+	late := func(w http.ResponseWriter, r *http.Request, ps Params) {
+		<-r.Context().Done()
+		panic("late")
+	}
+
+	// gone panics after the client went away.
+	var cancel context.CancelFunc
+	gone := func(w http.ResponseWriter, r *http.Request, ps Params) {
+		cancel()
+		panic("gone")
+	}
+
+	rt.Get("/router", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		panic("early")
+	})
+	rt.HandleWith("GET", "/route", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		panic("early")
+	}, page)
+	rt.Get("/router/late", late)
+	rt.HandleWith("GET", "/route/late", late, page)
+	rt.Get("/router/gone", gone)
+	rt.HandleWith("GET", "/route/gone", gone, page)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/router", http.StatusInternalServerError, "router early"},
+		{"/route", http.StatusServiceUnavailable, "route early"},
+
+		// The deadline of Timeouts does not mean that the client is gone.
+		{"/router/late", http.StatusInternalServerError, "router late"},
+		{"/route/late", http.StatusServiceUnavailable, "route late"},
+
+		// The panics of requests which client is gone are only logged.
+		{"/router/gone", http.StatusOK, ""},
+		{"/route/gone", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+
+		req := httptest.NewRequest("GET", tt.path, nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+		cancel()
+
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+
+	if want := []string{"/router/gone", "/route/gone"}; !reflect.DeepEqual(logged, want) {
+		t.Errorf("got logged %q, want %q", logged, want)
+	}
+}
+
+func TestWhen(t *testing.T) {
+	beta := func(r *http.Request) bool { return r.Header.Get("X-Beta") != "" }
+	office := func(r *http.Request) bool { return r.Header.Get("X-Office") != "" }
+
+	rt := New()
+	rt.HandleWith("GET", "/search", reply("office beta"), When(beta, office))
+	rt.HandleWith("GET", "/other", reply("office beta"), When(beta), When(office))
+	rt.Get("/search", reply("stable"))
+	rt.Get("/other", reply("stable"))
+
+	tests := []struct {
+		headers []string
+		body    string
+	}{
+		{[]string{"X-Beta", "1", "X-Office", "1"}, "office beta"},
+
+		// Requests that fail some predicate fall through to the route
+		// without predicates.
+		{[]string{"X-Beta", "1"}, "stable"},
+		{[]string{"X-Office", "1"}, "stable"},
+		{nil, "stable"},
+	}
+
+	for _, tt := range tests {
+		for _, path := range []string{"/search", "/other"} {
+			if w := serve(rt, "GET", path, tt.headers...); w.Body.String() != tt.body {
+				t.Errorf("%s with %q: got %q, want %q", path, tt.headers, w.Body.String(), tt.body)
+			}
+		}
+	}
+}
+
+func TestRequireClientCert(t *testing.T) {
+	rt := New()
+	rt.HandleWith("GET", "/admin", reply("admin"), RequireClientCert())
+
+	verified := [][]*x509.Certificate{{&x509.Certificate{}}}
+
+	tests := []struct {
+		name   string
+		state  *tls.ConnectionState
+		status int
+	}{
+		{"without TLS", nil, http.StatusForbidden},
+		{"without certificate", &tls.ConnectionState{}, http.StatusForbidden},
+		{"with verified certificate", &tls.ConnectionState{VerifiedChains: verified}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req.TLS = tt.state
+
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
+
+func TestDisableTrace(t *testing.T) {
+	rt := New()
+	rt.NotFoundBody = []byte("not found")
+	rt.Get("/a", reply("get"))
+	rt.Handle("TRACE", "/a", reply("trace"))
+
+	tests := []struct {
+		disable bool
+		hide405 bool
+		path    string
+		status  int
+		allow   string
+		body    string
+	}{
+		{false, false, "/a", http.StatusOK, "", "trace"},
+		{true, false, "/a", http.StatusMethodNotAllowed, "GET", ""},
+		{true, false, "/missing", http.StatusMethodNotAllowed, "", ""},
+
+		// Hide405 hides the methods of the path.
+		{true, true, "/a", http.StatusNotFound, "", "not found"},
+		{true, true, "/missing", http.StatusNotFound, "", "not found"},
+	}
+
+	for _, tt := range tests {
+		rt.DisableTrace, rt.Hide405 = tt.disable, tt.hide405
+
+		w := serve(rt, "TRACE", tt.path)
+		if w.Code != tt.status || w.Header().Get("Allow") != tt.allow || w.Body.String() != tt.body {
+			t.Errorf("%s with disable %v, hide405 %v: got %d %q with Allow %q, want %d %q with %q",
+				tt.path, tt.disable, tt.hide405, w.Code, w.Body.String(), w.Header().Get("Allow"),
+				tt.status, tt.body, tt.allow)
+		}
+
+		if _, ok := w.Header()["Allow"]; ok && tt.hide405 {
+			t.Errorf("%s: got Allow header with hide405", tt.path)
+		}
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	rt := New()
+	rt.Get("/a", reply("a"))
+	rt.Get("/health", reply("ok"))
+	rt.MaintenancePaths = []string{"/Health/"}
+	rt.RetryAfter = 1500 * time.Millisecond
+	rt.Maintenance.Store(true)
+
+	tests := []struct {
+		path       string
+		status     int
+		retryAfter string
+		body       string
+	}{
+		{"/a", http.StatusServiceUnavailable, "2", ""},
+		{"/missing", http.StatusServiceUnavailable, "2", ""},
+
+		// Allowed paths are routed as usual.
+		{"/health", http.StatusOK, "", "ok"},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, "GET", tt.path)
+		if w.Code != tt.status || w.Header().Get("Retry-After") != tt.retryAfter || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q with Retry-After %q, want %d %q with %q", tt.path, w.Code,
+				w.Body.String(), w.Header().Get("Retry-After"), tt.status, tt.body, tt.retryAfter)
+		}
+	}
+
+	rt.MaintenanceHandler = func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("down"))
+	}
+
+	if w := serve(rt, "GET", "/a"); w.Body.String() != "down" || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("got %q with headers %v, want %q", w.Body.String(), w.Header(), "down")
+	}
+
+	rt.Maintenance.Store(false)
+	if w := serve(rt, "GET", "/a"); w.Code != http.StatusOK {
+		t.Errorf("got %d after maintenance, want 200", w.Code)
+	}
+}
+
+func TestResourceNotFound(t *testing.T) {
+	user := func(w http.ResponseWriter, r *http.Request, ps Params) {
+		if id, _ := ps.Get("id"); id != "1" {
+			ResourceNotFound(w, r, ps)
+			return
+		}
+
+		w.Write([]byte("user 1"))
+	}
+
+	rt, sub := New(), New()
+	rt.NotFoundBody = []byte("no route")
+	rt.MissingResource = func(w http.ResponseWriter, r *http.Request, ps Params) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no such user"))
+	}
+	rt.Get("/users/:id", user)
+	rt.Mount("/sub", sub)
+	sub.Get("/users/:id", user)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/users/1", http.StatusOK, "user 1"},
+		{"/users/2", http.StatusNotFound, "no such user"},
+		{"/missing", http.StatusNotFound, "no route"},
+
+		// The mounted router has no MissingResource handler.
+		{"/sub/users/2", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+}
+
+func TestParamDecoder(t *testing.T) {
+	rt := New()
+	rt.ParamDecoder("id", func(s string) (string, error) {
+		b := []byte(s)
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+
+		return string(b), nil
+	})
+	rt.ParamDecoder("code", func(s string) (string, error) {
+		if s == "bad" {
+			return "", errors.New("invalid code")
+		}
+
+		return s, nil
+	})
+	rt.Get("/users/:id/:code", echo)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		// Query values are not decoded.
+		{"/users/abc/ok?id=xyz", http.StatusOK, "/users/abc/ok map[code:[ok] id:[cba xyz]]"},
+		{"/users/abc/bad", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"net/http"
+)
+
+// HandleBySize sets handlers for specific method and pattern that are chosen
+// by the size of the request body: requests with Content-Length less than
+// threshold are served by small, and other requests by large, for example
+// to process small uploads inline and queue large ones:
+//
+//	err := HandleBySize("POST", "/upload", 1<<20, storeUpload, queueUpload)
+//
+// Requests of unknown length, such as requests with chunked body, are
+// served by large, as their body may be of any size.
+func (r *Router) HandleBySize(method string, pattern string, threshold int64, small, large HandlerFunc) error {
+	return r.Handle(method, pattern, func(w http.ResponseWriter, req *http.Request, ps Params) {
+		if req.ContentLength >= 0 && req.ContentLength < threshold {
+			small(w, req, ps)
+			return
+		}
+
+		large(w, req, ps)
+	})
+}
@@ -0,0 +1,38 @@
+package router
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleBySize(t *testing.T) {
+	rt := New()
+	rt.HandleBySize("POST", "/upload", 10, reply("small"), reply("large"))
+
+	tests := []struct {
+		body   string
+		length int64
+		want   string
+	}{
+		{"abc", 3, "small"},
+		{"012345678", 9, "small"},
+		{"0123456789", 10, "large"},
+		{"0123456789ab", 12, "large"},
+
+		// Requests of unknown length are large.
+		{"a", -1, "large"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/upload", strings.NewReader(tt.body))
+		req.ContentLength = tt.length
+
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+
+		if w.Body.String() != tt.want {
+			t.Errorf("length %d: got %q, want %q", tt.length, w.Body.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Errors of streaming server-sent events.
+var (
+	ErrFlushUnsupported error = errors.New("router: response writer does not support flushing")
+	ErrSSEClosed        error = errors.New("router: event stream is closed")
+)
+
+// An SSEWriter sends server-sent events to the client. It is returned by
+// SSE.
+type SSEWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	closed  bool
+}
+
+// SSE starts a stream of server-sent events: it sets "Content-Type:
+// text/event-stream" and "Cache-Control: no-cache" headers, sends them with
+// 200 OK status code and returns the writer of the events, for example:
+//
+//	events, err := SSE(w)
+//	if err != nil {
+//		return
+//	}
+//
+//	defer events.Close()
+//	err = events.Send("update", `{"id":7}`)
+//
+// Returns ErrFlushUnsupported without writing anything if the writer, or
+// any writer it wraps, does not implement http.Flusher, as events would not
+// reach the client until the handler returns.
+func SSE(w http.ResponseWriter) (*SSEWriter, error) {
+	f, ok := findFlusher(w)
+	if !ok {
+		return nil, ErrFlushUnsupported
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+
+	return &SSEWriter{w: w, flusher: f}, nil
+}
+
+// Send sends the event with the data and flushes it to the client. Empty
+// event sends the data as a message without event type. Data with several
+// lines is sent as several data fields, that the client joins back.
+func (s *SSEWriter) Send(event string, data string) error {
+	if s.closed {
+		return ErrSSEClosed
+	}
+
+	var b strings.Builder
+	if event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	if _, err := io.WriteString(s.w, b.String()); err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+
+	return nil
+}
+
+// Close ends the stream, after that Send returns ErrSSEClosed. The response
+// is finished when the handler returns.
+func (s *SSEWriter) Close() error {
+	s.closed = true
+	return nil
+}
+
+// findFlusher returns the writer itself if it implements http.Flusher, or
+// the first writer that implements it among the writers it wraps.
+func findFlusher(w http.ResponseWriter) (http.Flusher, bool) {
+	for {
+		if f, ok := w.(http.Flusher); ok {
+			return f, true
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+
+		w = u.Unwrap()
+	}
+}
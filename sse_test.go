@@ -0,0 +1,44 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSSE(t *testing.T) {
+	rt := New()
+	rt.Use(Gzip())
+	rt.Get("/events", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		events, err := SSE(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		events.Send("update", `{"id":7}`)
+		events.Send("", "first\nsecond")
+		events.Close()
+
+		if err := events.Send("update", ""); err != ErrSSEClosed {
+			t.Errorf("got %v after close, want %v", err, ErrSSEClosed)
+		}
+	})
+
+	w := serve(rt, "GET", "/events", "Accept-Encoding", "gzip")
+
+	// Events are flushed through gzip.
+	want := "event: update\ndata: {\"id\":7}\n\ndata: first\ndata: second\n\n"
+	if body := gunzip(t, w.Body); body != want || !w.Flushed {
+		t.Errorf("got flushed %v %q, want %q", w.Flushed, body, want)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("got Content-Type %q, want text/event-stream", ct)
+	}
+
+	// The writer hides Flush of the recorder.
+	plain := struct{ http.ResponseWriter }{httptest.NewRecorder()}
+	if _, err := SSE(plain); err != ErrFlushUnsupported {
+		t.Errorf("got %v, want %v", err, ErrFlushUnsupported)
+	}
+}
@@ -0,0 +1,213 @@
+package router
+
+import (
+	"sort"
+)
+
+// routeTable holds the routes of a router. A table is never changed after
+// it is used by the router: routes are added to and removed from a copy,
+// that replaces the table, so that requests are matched without locking.
+type routeTable struct {
+	routes  map[string]*pathData
+	dynamic []*pathData
+}
+
+// newRouteTable returns an empty route table.
+func newRouteTable() *routeTable {
+	return &routeTable{routes: map[string]*pathData{}}
+}
+
+// clone returns a copy of the table that shares path data with it.
+func (t *routeTable) clone() *routeTable {
+	c := &routeTable{
+		routes:  make(map[string]*pathData, len(t.routes)+1),
+		dynamic: append([]*pathData(nil), t.dynamic...),
+	}
+
+	for k, v := range t.routes {
+		c.routes[k] = v
+	}
+
+	return c
+}
+
+// add registers new path data in the table.
+func (t *routeTable) add(pd *pathData) {
+	t.routes[pd.path] = pd
+
+	// Static paths are matched by the map lookup.
+	if pd.static() {
+		return
+	}
+
+	t.dynamic = append(t.dynamic, pd)
+	t.sort()
+}
+
+// replace replaces the path data with its changed copy.
+func (t *routeTable) replace(old *pathData, pd *pathData) {
+	t.routes[pd.path] = pd
+	for i, v := range t.dynamic {
+		if v == old {
+			t.dynamic[i] = pd
+		}
+	}
+}
+
+// sort keeps the dynamic path data sorted in matching order.
+func (t *routeTable) sort() {
+	sort.Slice(t.dynamic, func(i, j int) bool {
+		return t.dynamic[i].morePreferred(t.dynamic[j])
+	})
+}
+
+// remove removes path data from the table.
+func (t *routeTable) remove(pd *pathData) {
+	delete(t.routes, pd.path)
+
+	// Dynamic path data is copied, so that the order is kept.
+	dynamic := make([]*pathData, 0, len(t.dynamic))
+	for _, v := range t.dynamic {
+		if v != pd {
+			dynamic = append(dynamic, v)
+		}
+	}
+
+	t.dynamic = dynamic
+}
+
+// sorted returns path data in lexical order of the paths.
+func (t *routeTable) sorted() []*pathData {
+	paths := make([]string, 0, len(t.routes))
+	for p := range t.routes {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	pds := make([]*pathData, len(paths))
+	for i, p := range paths {
+		pds[i] = t.routes[p]
+	}
+
+	return pds
+}
+
+// clone returns a copy of the path data that can be changed without
+// affecting the original.
+func (pd *pathData) clone() *pathData {
+	c := *pd
+	c.methods = make(pathMethods, len(pd.methods))
+	for m, routes := range pd.methods {
+		c.methods[m] = append([]*route(nil), routes...)
+	}
+
+	return &c
+}
+
+// resetFlags sets the priority and the conditional flag of the path data
+// from its routes, after some of them were removed.
+func (pd *pathData) resetFlags() {
+	pd.priority = 0
+	pd.conditional = false
+	for _, routes := range pd.methods {
+		for _, rt := range routes {
+			pd.priority = max(pd.priority, rt.priority)
+			pd.conditional = pd.conditional || len(rt.predicates) > 0
+		}
+	}
+}
+
+// routerConfig holds the settings of a router that are read by every
+// request. Like the route table, it is never changed after it is used by
+// the router: settings are changed in a copy, that replaces the config.
+type routerConfig struct {
+	middleware   []middlewareEntry
+	fromHeaders  map[string]string
+	decoders     map[string]func(string) (string, error)
+	hosts        map[string]*Router
+	hostPatterns []*hostPattern
+}
+
+// emptyConfig is the config of a router without settings.
+var emptyConfig = &routerConfig{}
+
+// loadConfig returns the current config of the router.
+func (r *Router) loadConfig() *routerConfig {
+	if c := r.config.Load(); c != nil {
+		return c
+	}
+
+	return emptyConfig
+}
+
+// clone returns a copy of the config that can be changed without affecting
+// the original.
+func (c *routerConfig) clone() *routerConfig {
+	n := &routerConfig{
+		middleware:   append([]middlewareEntry(nil), c.middleware...),
+		fromHeaders:  make(map[string]string, len(c.fromHeaders)+1),
+		decoders:     make(map[string]func(string) (string, error), len(c.decoders)+1),
+		hosts:        make(map[string]*Router, len(c.hosts)+1),
+		hostPatterns: append([]*hostPattern(nil), c.hostPatterns...),
+	}
+
+	for k, v := range c.fromHeaders {
+		n.fromHeaders[k] = v
+	}
+
+	for k, v := range c.decoders {
+		n.decoders[k] = v
+	}
+
+	for k, v := range c.hosts {
+		n.hosts[k] = v
+	}
+
+	return n
+}
+
+// hostRouters returns the routers of the hosts, with and without
+// parameters.
+func (c *routerConfig) hostRouters() []*Router {
+	hosts := make([]*Router, 0, len(c.hosts)+len(c.hostPatterns))
+	for _, hr := range c.hosts {
+		hosts = append(hosts, hr)
+	}
+
+	for _, hp := range c.hostPatterns {
+		hosts = append(hosts, hp.router)
+	}
+
+	return hosts
+}
+
+// A Snapshot is an immutable copy of the routes of a router, that can
+// replace the routes of another router, or of the same router later.
+type Snapshot struct {
+	table *routeTable
+}
+
+// Snapshot returns the routes of the router. Routes added or removed later
+// do not change the snapshot. Middleware and other settings of the router
+// are not included.
+func (r *Router) Snapshot() *Snapshot {
+	return &Snapshot{table: r.table.Load()}
+}
+
+// Swap atomically replaces all routes of the router with the routes of the
+// snapshot and returns a snapshot of the replaced routes. A new route table
+// can be built in a separate router and swapped in while this router is
+// serving requests, for example to reload configuration:
+//
+//	next := New()
+//	// Register routes of the new configuration in next.
+//	prev := r.Swap(next.Snapshot())
+//
+// Requests being served keep using the routes they were matched with.
+func (r *Router) Swap(s *Snapshot) *Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Snapshot{table: r.table.Swap(s.table)}
+}
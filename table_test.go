@@ -0,0 +1,81 @@
+package router
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSwap(t *testing.T) {
+	rt := New()
+	rt.Get("/version", reply("1"))
+	rt.Get("/old/:id", reply("old"))
+	old := rt.Snapshot()
+
+	next := New()
+	next.Get("/version", reply("2"))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				// Every request sees one of the tables.
+				if body := serve(rt, "GET", "/version").Body.String(); body != "1" && body != "2" {
+					t.Errorf("got %q, want 1 or 2", body)
+					return
+				}
+			}
+		}()
+	}
+
+	// Settings are changed under load too.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			rt.Use(func(h HandlerFunc) HandlerFunc { return h })
+			rt.ParamFromHeader("tenant", "X-Tenant")
+			rt.ParamDecoder("id", func(s string) (string, error) { return s, nil })
+			rt.Host("example.com")
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		rt.Swap(next.Snapshot())
+		rt.Swap(old)
+	}
+
+	close(stop)
+	wg.Wait()
+
+	if prev := rt.Swap(next.Snapshot()); prev.table != old.table {
+		t.Error("Swap returned wrong snapshot")
+	}
+
+	if body := serve(rt, "GET", "/version").Body.String(); body != "2" {
+		t.Errorf("got %q after swap, want 2", body)
+	}
+
+	if w := serve(rt, "GET", "/old/1"); w.Code != 404 {
+		t.Errorf("got %d for removed route, want 404", w.Code)
+	}
+
+	// Routes added after swapping do not change the snapshot.
+	rt.Get("/new", reply("new"))
+	if w := serve(next, "GET", "/new"); w.Code != 404 {
+		t.Errorf("got %d from snapshot source, want 404", w.Code)
+	}
+
+	rt.Swap(old)
+	if body := serve(rt, "GET", "/old/1").Body.String(); body != "old" {
+		t.Errorf("got %q after swapping back, want old", body)
+	}
+}
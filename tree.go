@@ -0,0 +1,125 @@
+package router
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Tree returns the registered patterns as an indented text tree of their
+// segments, with the methods of the routes in brackets, for example:
+//
+//	/ [GET]
+//	  api
+//	    users [GET POST]
+//	      :id [DELETE GET]
+//	  static (mounted)
+//	    *path [GET]
+//
+// Segments of the same parent are in lexical order, routes for any method
+// are listed as ANY, and routes of mounted routers are nested under the
+// mount pattern, merged with the routes of the router that share its
+// segments. It is meant for debugging, and the format may change.
+func (r *Router) Tree() string {
+	var b strings.Builder
+	r.tree().write(&b, 0)
+
+	return b.String()
+}
+
+// treeNode is a segment of the patterns rendered by Router.Tree.
+type treeNode struct {
+	label    string
+	methods  []string
+	mounted  bool
+	children []*treeNode
+}
+
+// tree builds the tree of the registered patterns.
+func (r *Router) tree() *treeNode {
+	root := &treeNode{label: "/"}
+	for _, pd := range r.table.Load().sorted() {
+		n := root
+		for _, seg := range pd.segments {
+			n = n.child(seg.label())
+		}
+
+		// Routes of the mounted router go under the mount pattern, and its
+		// root route is the mount pattern itself.
+		if pd.mount != nil {
+			n.mounted = true
+			n.merge(pd.mount.tree())
+
+			continue
+		}
+
+		for _, m := range pd.allowedMethods() {
+			if m == anyMethod {
+				m = "ANY"
+			}
+
+			n.methods = append(n.methods, m)
+		}
+	}
+
+	root.sort()
+
+	return root
+}
+
+// merge adds the methods and the children of the other node to the node,
+// merging the children with the same labels.
+func (n *treeNode) merge(other *treeNode) {
+	n.methods = append(n.methods, other.methods...)
+	n.mounted = n.mounted || other.mounted
+	for _, c := range other.children {
+		n.child(c.label).merge(c)
+	}
+}
+
+// sort orders the methods and the children of the node and its children,
+// that are out of order after merging mounted routers.
+func (n *treeNode) sort() {
+	slices.Sort(n.methods)
+	n.methods = slices.Compact(n.methods)
+
+	sort.Slice(n.children, func(i, j int) bool {
+		return n.children[i].label < n.children[j].label
+	})
+
+	for _, c := range n.children {
+		c.sort()
+	}
+}
+
+// child returns the child node with the label, adding it if needed.
+func (n *treeNode) child(label string) *treeNode {
+	for _, c := range n.children {
+		if c.label == label {
+			return c
+		}
+	}
+
+	c := &treeNode{label: label}
+	n.children = append(n.children, c)
+
+	return c
+}
+
+// write renders the node and its children indented by depth levels.
+func (n *treeNode) write(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth) + n.label)
+	if len(n.methods) > 0 {
+		b.WriteString(" [" + strings.Join(n.methods, " ") + "]")
+	}
+
+	if n.mounted {
+		b.WriteString(" (mounted)")
+	}
+
+	b.WriteString("\n")
+
+	for _, c := range n.children {
+		c.write(b, depth+1)
+	}
+}
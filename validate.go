@@ -0,0 +1,92 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// A Rule describes a parameter checked by middleware returned by Validate.
+type Rule struct {
+	// Param is the name of the parameter in Params.
+	Param string
+
+	// Required makes requests without the parameter invalid.
+	Required bool
+
+	// Type is one of the built-in parameter types of patterns: "int",
+	// "float", "uuid" or "slug". All values of the parameter must be of
+	// the type, compared in lower case like paths. Empty type accepts any
+	// value.
+	Type string
+}
+
+// A FieldError describes a parameter that failed validation.
+type FieldError struct {
+	Param  string `json:"param"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Validate returns middleware that checks the parameters of requests
+// against the rules before the handler runs, for example:
+//
+//	validate, err := Validate([]Rule{
+//		{Param: "page", Type: "int"},
+//		{Param: "q", Required: true},
+//	})
+//
+// If some parameters are invalid, the middleware responds with 400 Bad
+// Request and a JSON object with all of them listed in "errors" as
+// FieldError values, and the handler is not called. Returns an error
+// wrapping ErrParameterPattern if a rule has an unknown type.
+func Validate(rules []Rule) (Middleware, error) {
+	types := make([]*paramType, len(rules))
+	for i, rule := range rules {
+		if rule.Type == "" {
+			continue
+		}
+
+		t, ok := paramTypes[rule.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown parameter type %q", ErrParameterPattern, rule.Type)
+		}
+
+		types[i] = &t
+	}
+
+	return func(h HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, ps Params) {
+			var errs []FieldError
+			for i, rule := range rules {
+				values := ps[rule.Param]
+				if len(values) == 0 {
+					if rule.Required {
+						errs = append(errs, FieldError{Param: rule.Param, Reason: "missing"})
+					}
+
+					continue
+				}
+
+				if types[i] == nil {
+					continue
+				}
+
+				// Report the first invalid value only.
+				for _, v := range values {
+					if !types[i].re.MatchString(strings.ToLower(v)) {
+						errs = append(errs, FieldError{Param: rule.Param, Value: v, Reason: "not a valid " + rule.Type})
+						break
+					}
+				}
+			}
+
+			if len(errs) > 0 {
+				JSON(w, http.StatusBadRequest, map[string]interface{}{"errors": errs})
+				return
+			}
+
+			h(w, r, ps)
+		}
+	}, nil
+}
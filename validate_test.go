@@ -0,0 +1,46 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if _, err := Validate([]Rule{{Param: "day", Type: "date"}}); !errors.Is(err, ErrParameterPattern) {
+		t.Errorf("got %v, want %v", err, ErrParameterPattern)
+	}
+
+	validate, err := Validate([]Rule{
+		{Param: "page", Type: "int"},
+		{Param: "q", Required: true},
+		{Param: "id", Type: "uuid", Required: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := New()
+	rt.HandleWith("GET", "/search", reply("ok"), WithMiddleware(validate))
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/search?q=a&id=123E4567-e89b-12d3-a456-426614174000", http.StatusOK, "ok"},
+		{"/search?page=2&q=a&id=123e4567-e89b-12d3-a456-426614174000", http.StatusOK, "ok"},
+
+		// All invalid parameters are reported.
+		{"/search?page=x&page=y", http.StatusBadRequest, `{"errors":[` +
+			`{"param":"page","value":"x","reason":"not a valid int"},` +
+			`{"param":"q","reason":"missing"},` +
+			`{"param":"id","reason":"missing"}]}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		if w := serve(rt, "GET", tt.path); w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Version returns a group to register routes of an API version, with the
+// paths prefixed by the version, for example:
+//
+//	v1 := Version("v1")
+//	v1.Deprecated = true
+//	err := v1.Get("/users", usersHandler)
+//
+// registers "/v1/users". Routes are added to this router.
+func (r *Router) Version(version string) *VersionGroup {
+	return &VersionGroup{router: r, prefix: "/" + strings.Trim(version, "/")}
+}
+
+// A VersionGroup registers routes of an API version. It is returned by
+// Router.Version.
+type VersionGroup struct {
+	router *Router
+	prefix string
+
+	// Deprecated makes the routes of the version add the "Deprecation: true"
+	// header to their responses. It applies to the routes registered before
+	// it was set too.
+	Deprecated bool
+}
+
+// Handle sets an HTTP request handler for specific method and pattern
+// prefixed by the version, like Router.Handle does.
+func (g *VersionGroup) Handle(method string, pattern string, handler HandlerFunc) error {
+	return g.HandleWith(method, pattern, handler)
+}
+
+// HandleWith is like Handle, but additionally applies route options, like
+// Router.HandleWith does.
+func (g *VersionGroup) HandleWith(method string, pattern string, handler HandlerFunc, opts ...RouteOption) error {
+	// Deprecation middleware runs before the route middleware.
+	opts = append([]RouteOption{WithMiddleware(g.deprecation)}, opts...)
+
+	return g.router.HandleWith(method, joinPattern(g.prefix, pattern), handler, opts...)
+}
+
+// Get adds handler for GET request.
+func (g *VersionGroup) Get(pattern string, handler HandlerFunc) error {
+	return g.Handle("GET", pattern, handler)
+}
+
+// Put adds handler for PUT request.
+func (g *VersionGroup) Put(pattern string, handler HandlerFunc) error {
+	return g.Handle("PUT", pattern, handler)
+}
+
+// Post adds handler for POST request.
+func (g *VersionGroup) Post(pattern string, handler HandlerFunc) error {
+	return g.Handle("POST", pattern, handler)
+}
+
+// Delete adds handler for DELETE request.
+func (g *VersionGroup) Delete(pattern string, handler HandlerFunc) error {
+	return g.Handle("DELETE", pattern, handler)
+}
+
+// deprecation is middleware that marks responses of deprecated versions.
+func (g *VersionGroup) deprecation(h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, ps Params) {
+		if g.Deprecated {
+			w.Header().Set("Deprecation", "true")
+		}
+
+		h(w, r, ps)
+	}
+}
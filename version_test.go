@@ -0,0 +1,33 @@
+package router
+
+import "testing"
+
+func TestVersion(t *testing.T) {
+	rt := New()
+	v1 := rt.Version("v1")
+	v2 := rt.Version("/v2/")
+	v1.Get("/users", reply("v1 users"))
+	v1.Get("/", reply("v1 root"))
+	v2.Get("/users", reply("v2 users"))
+
+	// Deprecation applies to the routes registered before it was set too.
+	v1.Deprecated = true
+
+	tests := []struct {
+		path        string
+		body        string
+		deprecation string
+	}{
+		{"/v1/users", "v1 users", "true"},
+		{"/v1", "v1 root", "true"},
+		{"/v2/users", "v2 users", ""},
+	}
+
+	for _, tt := range tests {
+		w := serve(rt, "GET", tt.path)
+		if w.Body.String() != tt.body || w.Header().Get("Deprecation") != tt.deprecation {
+			t.Errorf("%s: got %q with Deprecation %q, want %q with %q",
+				tt.path, w.Body.String(), w.Header().Get("Deprecation"), tt.body, tt.deprecation)
+		}
+	}
+}
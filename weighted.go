@@ -0,0 +1,57 @@
+package router
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"sort"
+)
+
+// ErrWeight is returned by HandleWeighted if no handlers are passed or some
+// handler has weight that is not positive.
+var ErrWeight error = errors.New("router: weighted handlers must have positive weights")
+
+// A WeightedHandler is a handler that serves a share of requests
+// proportional to its weight.
+type WeightedHandler struct {
+	Weight  int
+	Handler HandlerFunc
+}
+
+// HandleWeighted sets handlers for specific method and pattern that share
+// the requests by their weights, for example to send a tenth of requests to
+// a canary deployment:
+//
+//	err := HandleWeighted("GET", "/api/search", []WeightedHandler{
+//		{90, searchHandler},
+//		{10, canarySearchHandler},
+//	})
+//
+// The handler is chosen at random for every request, so the split is only
+// approximate for a small number of requests.
+func (r *Router) HandleWeighted(method string, pattern string, handlers []WeightedHandler) error {
+	if len(handlers) == 0 {
+		r.addError(method, pattern, ErrWeight)
+		return ErrWeight
+	}
+
+	// Upper bounds of handler shares, in the order of handlers.
+	bounds := make([]int, len(handlers))
+	total := 0
+	for i, wh := range handlers {
+		if wh.Weight <= 0 {
+			r.addError(method, pattern, ErrWeight)
+			return ErrWeight
+		}
+
+		total += wh.Weight
+		bounds[i] = total
+	}
+
+	handlers = append([]WeightedHandler(nil), handlers...)
+
+	return r.Handle(method, pattern, func(w http.ResponseWriter, req *http.Request, ps Params) {
+		n := rand.Intn(total)
+		handlers[sort.SearchInts(bounds, n+1)].Handler(w, req, ps)
+	})
+}
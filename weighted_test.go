@@ -0,0 +1,37 @@
+package router
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHandleWeighted(t *testing.T) {
+	rt := New()
+	for _, handlers := range [][]WeightedHandler{nil, {{90, reply("a")}, {0, reply("b")}}} {
+		if err := rt.HandleWeighted("GET", "/invalid", handlers); err != ErrWeight {
+			t.Errorf("%v: got %v, want %v", handlers, err, ErrWeight)
+		}
+	}
+
+	if err := rt.Build(); !errors.Is(err, ErrWeight) {
+		t.Errorf("got %v from Build, want %v", err, ErrWeight)
+	}
+
+	rt.HandleWeighted("GET", "/search", []WeightedHandler{
+		{90, reply("stable")},
+		{10, reply("canary")},
+	})
+
+	const n = 10000
+
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		counts[serve(rt, "GET", "/search").Body.String()]++
+	}
+
+	// The bounds are about 6.7 standard deviations away from the expected
+	// count of 1000, so the test practically never fails by chance.
+	if c := counts["canary"]; c < 800 || c > 1200 || counts["stable"] != n-c {
+		t.Errorf("got %v, want about a tenth of %d requests served by canary", counts, n)
+	}
+}